@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
+	"runtime"
+	"time"
 
 	"github.com/spf13/cobra"
-	yaml "gopkg.in/yaml.v2"
 
+	"github.com/drlau/akashi/pkg/baseline"
 	"github.com/drlau/akashi/pkg/compare"
 	"github.com/drlau/akashi/pkg/plan"
 	"github.com/drlau/akashi/pkg/ruleset"
@@ -19,42 +23,70 @@ const (
 	createKey  = "create"
 	destroyKey = "destroy"
 	updateKey  = "update"
+	stateKey   = "state"
+	readKey    = "read"
 )
 
 // TODO: set this dynamically
 const version = "0.0.5"
 
+const (
+	terraformBinEnv     = "AKASHI_TERRAFORM_BIN"
+	defaultTerraformBin = "terraform"
+)
+
 var (
-	file          string
-	versionOutput string
-	quiet         bool
-	json          bool
-	failedOnly    bool
-	strict        bool
-	noColor       bool
-	errorOnFail   bool
+	files              []string
+	parallelism        int
+	terragrunt         bool
+	terragruntRulesets []string
+	planFile           string
+	terraformBin       string
+	terraformTimeout   time.Duration
+	versionOutput      string
+	quiet              bool
+	json               bool
+	state              bool
+	jsonStream         bool
+	failedOnly         bool
+	strict             bool
+	noColor            bool
+	errorOnFail        bool
+	failOn             string
+	baselineFile       string
+	verbose            bool
 )
 
 func NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "akashi <path to ruleset>",
+		Use:   "akashi <path to ruleset>...",
 		Short: "Akashi / 証",
 		Long:  `Validate "terraform plan" changes against a customizable ruleset`,
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MinimumNArgs(1),
 		RunE:  run,
 	}
 
 	cmd.SetVersionTemplate(version)
 
-	cmd.Flags().StringVarP(&file, "file", "f", "", "read plan output from file")
+	cmd.Flags().StringArrayVarP(&files, "file", "f", nil, "read plan output from file, which can be a glob (can be repeated to evaluate several plans)")
+	cmd.Flags().IntVarP(&parallelism, "parallelism", "p", runtime.NumCPU(), "number of plans to evaluate at once when evaluating several plans")
+	cmd.Flags().BoolVar(&terragrunt, "terragrunt", false, "read the contents as the output from 'terragrunt run-all plan', and evaluate the plan of each module")
+	cmd.Flags().StringArrayVar(&terragruntRulesets, "terragrunt-ruleset", nil, "use a different ruleset for the terragrunt modules matching a pattern, written as pattern=path (can be repeated)")
+	cmd.Flags().StringVar(&planFile, "plan-file", "", "read a plan saved with 'terraform plan -out', decoding it with 'terraform show -json'")
+	cmd.Flags().StringVar(&terraformBin, "terraform-bin", "", fmt.Sprintf("terraform compatible executable used to decode --plan-file, such as 'tofu' (default $%s or %q)", terraformBinEnv, defaultTerraformBin))
+	cmd.Flags().DurationVar(&terraformTimeout, "terraform-timeout", 5*time.Minute, "timeout for decoding --plan-file")
 	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "compare only, and error if there is a failing rule")
 	cmd.Flags().BoolVar(&failedOnly, "failed-only", false, "only output failing lines")
 	cmd.Flags().BoolVarP(&strict, "strict", "s", false, "require all resources to match a comparer")
 	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable color output")
 	cmd.Flags().BoolVarP(&errorOnFail, "error-on-fail", "e", false, "for non-quiet runs, make akashi return exit code 1 on fails")
-	cmd.Flags().BoolVarP(&json, "json", "j", false, "read the contents as the output from 'terraform state show -json'")
-	// TODO
-	// cmd.Flags().BoolVarP(&verbose, "verbose", "V", false, "enable verbose output")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "the least severe failure that fails the run, either warning or error, which also sets the exit code of non-quiet runs (default error)")
+	cmd.Flags().StringVar(&baselineFile, "baseline", "", "only fail on violations that are not in the baseline created with 'akashi baseline create'")
+	cmd.Flags().BoolVarP(&json, "json", "j", false, "read the contents as the output from 'terraform show -json' of a saved plan (detected automatically)")
+	cmd.Flags().BoolVar(&jsonStream, "json-stream", false, "read the contents as the machine readable output from 'terraform plan -json' (detected automatically)")
+	cmd.Flags().BoolVar(&state, "state", false, "read the contents as the output from 'terraform show -json' of a state file, and validate every resource against the resources rules (detected automatically)")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "V", false, "enable verbose output")
+	addParameterFlags(cmd)
 
 	versionCmd := &cobra.Command{
 		Use:    "version",
@@ -64,85 +96,414 @@ func NewCommand() *cobra.Command {
 		},
 	}
 	cmd.AddCommand(versionCmd)
+	cmd.AddCommand(newValidateCommand())
+	cmd.AddCommand(newLintCommand())
+	cmd.AddCommand(newSchemaCommand())
+	cmd.AddCommand(newBaselineCommand())
 
 	return cmd
 }
 
 func run(_ *cobra.Command, args []string) error {
-	rulesetFile, err := ioutil.ReadFile(args[0])
+	if err := validateFailOn(); err != nil {
+		return err
+	}
+
+	if err := loadBaseline(); err != nil {
+		return err
+	}
+
+	rs, err := loadRuleset(args...)
 	if err != nil {
 		return err
 	}
 
-	var rs ruleset.Ruleset
-	err = yaml.Unmarshal(rulesetFile, &rs)
+	paths, err := expandFiles(files)
 	if err != nil {
 		return err
 	}
+	if err := checkPlanFile(paths); err != nil {
+		return err
+	}
+	if terragrunt || len(terragruntRulesets) > 0 {
+		return runTerragrunt(rs, paths)
+	}
+	if len(paths) > 1 {
+		out := utils.NewOutput(noColor)
+		os.Exit(runPlans(out, fileJobs(rs, paths)))
+	}
 
-	var in []plan.ResourceChange
-	var data io.Reader
+	var path string
+	if len(paths) == 1 {
+		path = paths[0]
+	}
+	src, err := openPlan(path)
+	if err != nil {
+		return err
+	}
+	defer src.close()
+	if verbose {
+		fmt.Fprintf(os.Stderr, "detected input format: %s\n", src.format)
 
-	if file != "" {
-		data, err = os.Open(file)
-		if err != nil {
-			return err
+		// the tool is only known once the plan is read
+		decode := src.decode
+		src.decode = func(fn func(plan.ResourceChange) error) (*plan.Plan, error) {
+			p, err := decode(fn)
+			if err == nil && p.Tool != "" {
+				fmt.Fprintf(os.Stderr, "detected tool: %s %s\n", p.Tool, p.ToolVersion)
+			}
+			return p, err
 		}
-	} else {
-		data = os.Stdin
 	}
 
-	if json {
-		in, err = plan.NewResourcePlanFromJSON(data)
+	c, err := newPlanComparers(rs, src.format)
+	if err != nil {
+		return err
+	}
+	src.requireValues = rs.ComparesValues()
+
+	if quiet {
+		pass, err := c.compareStream(src)
 		if err != nil {
 			return err
 		}
+		if !pass {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	out := utils.NewOutput(noColor)
+
+	exitCode, err := c.diffStream(out, src)
+	if err != nil {
+		return err
+	}
+	os.Exit(exitCode)
+	return nil
+}
+
+// planComparers are the comparers for a single plan
+type planComparers struct {
+	variables     *compare.VariablesComparer
+	configuration *compare.ConfigurationComparer
+	resources     map[string]compare.Comparer
+	waivers       *compare.Waivers
+	baseline      *baseline.Tracker
+}
+
+// errResourceFailed stops reading a plan once a resource fails, as the result is already known
+var errResourceFailed = errors.New("resource failed")
+
+// valuesOmittedError is returned when the ruleset compares values that the plan leaves out for the reason
+func valuesOmittedError(reason error) error {
+	return fmt.Errorf("%v, so rules that compare values cannot be evaluated", reason)
+}
+
+// newPlanComparers creates the comparers of the ruleset that apply to a plan in the format
+func newPlanComparers(rs ruleset.Ruleset, format plan.Format) (*planComparers, error) {
+	result := &planComparers{
+		resources: make(map[string]compare.Comparer),
+		waivers:   compare.NewWaivers(rs.Waivers, clock),
+		baseline:  baseline.NewTracker(knownViolations),
+	}
+
+	if rs.Variables != nil {
+		if format != plan.FormatJSONPlan {
+			return nil, fmt.Errorf("variables rules require a JSON plan")
+		}
+		result.variables = compare.NewVariablesComparer(*rs.Variables)
+	}
+
+	if rs.Configuration != nil {
+		if format != plan.FormatJSONPlan {
+			return nil, fmt.Errorf("configuration rules require a JSON plan")
+		}
+		result.configuration = compare.NewConfigurationComparer(*rs.Configuration)
+	}
+
+	if format == plan.FormatJSONStream && rs.ComparesValues() {
+		if !jsonStream {
+			return nil, fmt.Errorf("input looks like the output of 'terraform plan -json', which has no resource values to compare against the ruleset's rules; save the plan with 'terraform plan -out' and pass the output of 'terraform show -json' instead")
+		}
+		return nil, fmt.Errorf("the output of 'terraform plan -json' has no resource values, so rules that compare values cannot be evaluated; only rules with autoFail, or with ignoreExtraArgs and without enforced, ignored, enforceAll or requireAll, can be used")
+	}
+
+	if format == plan.FormatJSONState {
+		if rs.Resources == nil {
+			return nil, fmt.Errorf("validating a state requires resources rules")
+		}
+		result.resources[stateKey] = compare.NewStateComparer(*rs.Resources)
 	} else {
-		in, err = plan.NewResourcePlanFromPlanOutput(data)
-		if err != nil {
-			return err
+		if rs.CreatedResources != nil {
+			result.resources[createKey] = compare.NewCreateComparer(*rs.CreatedResources)
+		}
+		if rs.DestroyedResources != nil {
+			result.resources[destroyKey] = compare.NewDestroyComparer(*rs.DestroyedResources)
+		}
+		if rs.UpdatedResources != nil {
+			result.resources[updateKey] = compare.NewUpdateComparer(*rs.UpdatedResources)
+		}
+		if rs.ReadResources != nil {
+			result.resources[readKey] = compare.NewReadComparer(*rs.ReadResources)
 		}
 	}
 
-	comparers := make(map[string]compare.Comparer)
-	if rs.CreatedResources != nil {
-		comparers[createKey] = compare.NewCreateComparer(*rs.CreatedResources)
+	return result, nil
+}
+
+// compare returns true if the plan passes every rule
+func (c *planComparers) compare(p *plan.Plan) bool {
+	return c.comparePlan(p) && runCompare(p.ResourceChanges, c.resources, c.waivers, c.baseline) == 0
+}
+
+// comparePlan returns true if the plan passes the variables and configuration rules
+func (c *planComparers) comparePlan(p *plan.Plan) bool {
+	if c.variables != nil && !c.variables.Compare(p.Variables) && failsRun(c.variables.Severity(p.Variables)) {
+		return false
 	}
-	if rs.DestroyedResources != nil {
-		comparers[destroyKey] = compare.NewDestroyComparer(*rs.DestroyedResources)
+	if c.configuration != nil && !c.configuration.Compare(p.Configuration) {
+		return false
 	}
-	if rs.UpdatedResources != nil {
-		comparers[updateKey] = compare.NewUpdateComparer(*rs.UpdatedResources)
+	return true
+}
+
+// compareStream compares each resource as it is read from the source, stopping at the first failure
+func (c *planComparers) compareStream(src *planSource) (bool, error) {
+	p, err := src.decode(func(r plan.ResourceChange) error {
+		if !compareResource(r, c.resources, c.waivers, c.baseline) {
+			return errResourceFailed
+		}
+		return nil
+	})
+	if err == errResourceFailed {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
 	}
 
-	if quiet {
-		os.Exit(runCompare(in, comparers))
+	return c.comparePlan(p), nil
+}
+
+// diff writes the result of every rule for the plan, followed by the number of failures, and returns the exit code
+func (c *planComparers) diff(out io.Writer, p *plan.Plan) int {
+	counts := c.diffPlan(out, p)
+	counts.merge(runDiff(out, p.ResourceChanges, c.resources, c.waivers, c.baseline))
+	writeDrift(out, p.Drift)
+	counts.write(out)
+
+	return counts.exitCode()
+}
+
+// diffPlan writes the result of the variables and configuration rules for the plan and returns the failures
+func (c *planComparers) diffPlan(out io.Writer, p *plan.Plan) failureCounts {
+	counts := make(failureCounts)
+	if c.variables != nil {
+		counts.add(runVariablesDiff(out, p.Variables, c.variables))
 	}
-	out := utils.NewOutput(noColor)
+	if c.configuration != nil {
+		counts.add(runConfigurationDiff(out, p.Configuration, c.configuration))
+	}
+
+	return counts
+}
+
+// diffStream writes the result of each resource as it is read from the source, and returns the exit code
+// With variables or configuration rules, resource results are held until the plan is read to keep the same order as diff
+func (c *planComparers) diffStream(out io.Writer, src *planSource) (int, error) {
+	resourceOut := out
+	var buffered bytes.Buffer
+	if c.variables != nil || c.configuration != nil {
+		resourceOut = &buffered
+	}
+
+	counts := make(failureCounts)
+	p, err := src.decode(func(r plan.ResourceChange) error {
+		counts.add(diffResource(resourceOut, r, c.resources, c.waivers, c.baseline))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	counts.merge(c.diffPlan(out, p))
+	out.Write(buffered.Bytes())
+	writeDrift(out, p.Drift)
+	counts.write(out)
+	writeUnusedWaivers(out, c.waivers.Unused())
+	writeResolvedBaseline(out, c.baseline.Resolved())
+
+	return counts.exitCode(), nil
+}
+
+// writeDrift writes a warning for each resource that changed outside of the plan, as rules are not compared against them
+func writeDrift(out io.Writer, drift []plan.ResourceChange) {
+	for _, r := range drift {
+		change := "updated"
+		if r.IsDelete() {
+			change = "deleted"
+		}
+		fmt.Fprintf(out, "%s %s was %s outside of the plan\n", utils.Yellow("!"), r.GetAddress(), change)
+	}
+}
+
+// planSource is a plan that has been opened and its format detected, but not yet read
+type planSource struct {
+	format plan.Format
+
+	// decode reads the plan, calling fn with each resource change, and returns the plan without its resource changes
+	decode func(fn func(plan.ResourceChange) error) (*plan.Plan, error)
+
+	// requireValues makes decode fail if the plan leaves out the values of resources
+	requireValues bool
 
-	os.Exit(runDiff(out, in, comparers))
+	close func()
+}
+
+// readPlan parses the plan from --plan-file, the file at path, or stdin, and returns the format it was read as
+func readPlan(path string) (*plan.Plan, plan.Format, error) {
+	src, err := openPlan(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer src.close()
+
+	var changes []plan.ResourceChange
+	p, err := src.decode(func(r plan.ResourceChange) error {
+		changes = append(changes, r)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	p.ResourceChanges = changes
+
+	return p, src.format, nil
+}
+
+// checkPlanFile returns an error if --plan-file is set along with --file, as only one of them would be read
+func checkPlanFile(paths []string) error {
+	if planFile != "" && len(paths) > 0 {
+		return fmt.Errorf("--plan-file cannot be used with --file")
+	}
 	return nil
 }
 
-func runCompare(rc []plan.ResourceChange, comparers map[string]compare.Comparer) int {
-	createComparer, hasCreate := comparers[createKey]
-	destroyComparer, hasDestroy := comparers[destroyKey]
-	updateComparer, hasUpdate := comparers[updateKey]
+// openPlan opens the plan from --plan-file, the file at path, or stdin, and detects its format
+func openPlan(path string) (*planSource, error) {
+	requested, err := requestedFormat()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, r := range rc {
-		if r.IsCreate() && hasCreate {
-			if !createComparer.Compare(r) {
-				return 1
-			}
-		} else if r.IsDelete() && hasDestroy {
-			if !destroyComparer.Compare(r) {
-				return 1
+	if planFile != "" {
+		binary, err := plan.IsBinaryPlanFile(planFile)
+		if err != nil {
+			return nil, err
+		}
+		if binary {
+			if requested != "" && requested != plan.FormatJSONPlan {
+				return nil, fmt.Errorf("--plan-file is a saved plan, which cannot be read as a %s", requested)
 			}
-		} else if r.IsUpdate() && hasUpdate {
-			if !updateComparer.Compare(r) {
-				return 1
+
+			ctx, cancel := context.WithTimeout(context.Background(), terraformTimeout)
+			return &planSource{
+				format: plan.FormatJSONPlan,
+				decode: func(fn func(plan.ResourceChange) error) (*plan.Plan, error) {
+					return plan.DecodePlanFile(ctx, getTerraformBin(), planFile, fn)
+				},
+				close: cancel,
+			}, nil
+		}
+		// not a binary plan, so read it the same way as --file
+		path = planFile
+	}
+
+	var data io.Reader = os.Stdin
+	closeData := func() {}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		data = f
+		closeData = func() { f.Close() }
+	}
+
+	format, data, err := plan.DetectFormat(data)
+	if err != nil {
+		closeData()
+		return nil, err
+	}
+	if requested != "" && requested != format {
+		closeData()
+		return nil, fmt.Errorf("input was expected to be a %s, but looks like a %s", requested, format)
+	}
+
+	src := &planSource{
+		format: format,
+		close:  closeData,
+	}
+	src.decode = func(fn func(plan.ResourceChange) error) (*plan.Plan, error) {
+		if format == plan.FormatJSONPlan {
+			return plan.DecodeJSON(data, fn)
+		}
+
+		p, err := plan.NewPlanFromFormat(format, data)
+		if err != nil {
+			return nil, err
+		}
+		if p.ValuesOmitted != nil && src.requireValues {
+			return nil, valuesOmittedError(p.ValuesOmitted)
+		}
+		for _, r := range p.ResourceChanges {
+			if err := fn(r); err != nil {
+				return nil, err
 			}
-		} else if strict {
+		}
+		p.ResourceChanges = nil
+		return p, nil
+	}
+	return src, nil
+}
+
+// requestedFormat returns the input format set with flags, or an empty format if it should be detected
+func requestedFormat() (plan.Format, error) {
+	var formats []plan.Format
+	if json {
+		formats = append(formats, plan.FormatJSONPlan)
+	}
+	if jsonStream {
+		formats = append(formats, plan.FormatJSONStream)
+	}
+	if state {
+		formats = append(formats, plan.FormatJSONState)
+	}
+
+	switch len(formats) {
+	case 0:
+		return "", nil
+	case 1:
+		return formats[0], nil
+	default:
+		return "", fmt.Errorf("only one of --json, --json-stream and --state can be set")
+	}
+}
+
+// getTerraformBin returns the executable to decode plan files with, from the flag or the environment variable
+func getTerraformBin() string {
+	if terraformBin != "" {
+		return terraformBin
+	}
+	if bin := os.Getenv(terraformBinEnv); bin != "" {
+		return bin
+	}
+	return defaultTerraformBin
+}
+
+func runCompare(rc []plan.ResourceChange, comparers map[string]compare.Comparer, waivers *compare.Waivers, known *baseline.Tracker) int {
+	for _, r := range rc {
+		if !compareResource(r, comparers, waivers, known) {
 			return 1
 		}
 	}
@@ -150,46 +511,159 @@ func runCompare(rc []plan.ResourceChange, comparers map[string]compare.Comparer)
 	return 0
 }
 
-func runDiff(out io.Writer, rc []plan.ResourceChange, comparers map[string]compare.Comparer) int {
-	exitCode := 0
+// compareResource returns true if the resource passes the comparers for its action,
+// or only fails rules that are waived, in the baseline, or less severe than --fail-on
+func compareResource(r plan.ResourceChange, comparers map[string]compare.Comparer, waivers *compare.Waivers, known *baseline.Tracker) bool {
+	known.Evaluate(r.GetAddress())
+
+	matched := comparersFor(r, comparers)
+	if len(matched) == 0 {
+		return !strict || isBaselined(unmatchedViolations(r), known)
+	}
+	for _, comparer := range matched {
+		if comparer.Compare(r) {
+			continue
+		}
+		if !waivers.Waives(r.GetAddress(), comparer.RuleID(r)) &&
+			!isBaselined(resourceViolations(r, comparer), known) &&
+			failsRun(comparer.Severity(r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// comparersFor returns the comparers for the action of the resource
+// A replacement is compared against both the created and destroyed resources rules
+func comparersFor(r plan.ResourceChange, comparers map[string]compare.Comparer) []compare.Comparer {
 	createComparer, hasCreate := comparers[createKey]
 	destroyComparer, hasDestroy := comparers[destroyKey]
 	updateComparer, hasUpdate := comparers[updateKey]
+	stateComparer, hasState := comparers[stateKey]
+	readComparer, hasRead := comparers[readKey]
+
+	if r.IsCreate() && r.IsDelete() {
+		var result []compare.Comparer
+		if hasCreate {
+			result = append(result, createComparer)
+		}
+		if hasDestroy {
+			result = append(result, destroyComparer)
+		}
+		return result
+	}
 
+	if r.IsNoOp() && hasState {
+		return []compare.Comparer{stateComparer}
+	} else if r.IsCreate() && hasCreate {
+		return []compare.Comparer{createComparer}
+	} else if r.IsDelete() && hasDestroy {
+		return []compare.Comparer{destroyComparer}
+	} else if r.IsUpdate() && hasUpdate {
+		return []compare.Comparer{updateComparer}
+	} else if r.IsRead() && hasRead {
+		return []compare.Comparer{readComparer}
+	}
+
+	return nil
+}
+
+// runDiff writes the result of the comparer for the action of each resource and returns the failures
+func runDiff(out io.Writer, rc []plan.ResourceChange, comparers map[string]compare.Comparer, waivers *compare.Waivers, known *baseline.Tracker) failureCounts {
+	counts := make(failureCounts)
 	for _, r := range rc {
-		diff := ""
-		pass := true
-		if r.IsCreate() && hasCreate {
-			diff, pass = createComparer.Diff(r)
-		} else if r.IsDelete() && hasDestroy {
-			diff, pass = destroyComparer.Diff(r)
-		} else if r.IsUpdate() && hasUpdate {
-			diff, pass = updateComparer.Diff(r)
-		} else {
-			if !strict {
-				continue
-			}
+		counts.add(diffResource(out, r, comparers, waivers, known))
+	}
 
-			if errorOnFail {
-				exitCode = 1
-			}
-			fmt.Fprintln(out, fmt.Sprintf("%s %s (no matching comparer)", utils.Yellow("?"), r.GetAddress()))
-			continue
+	return counts
+}
+
+// diffResource writes the result of the comparers for the action of the resource, and returns
+// the most severe of the severity of a failure, waived, baselined, or an empty string if it passes
+func diffResource(out io.Writer, r plan.ResourceChange, comparers map[string]compare.Comparer, waivers *compare.Waivers, known *baseline.Tracker) string {
+	known.Evaluate(r.GetAddress())
+
+	matched := comparersFor(r, comparers)
+	if len(matched) == 0 {
+		if !strict {
+			return ""
 		}
-		if pass {
-			if failedOnly {
-				continue
-			}
 
+		return writeBaselineDiff(out, fmt.Sprintf("%s %s (no matching comparer)", utils.Yellow("?"), r.GetAddress()), unmatchedViolations(r), known, ruleset.SeverityError)
+	}
+
+	result := ""
+	for _, comparer := range matched {
+		result = moreSevere(result, diffComparer(out, r, comparer, waivers, known))
+	}
+	return result
+}
+
+// diffComparer writes the result of the comparer for the resource,
+// and returns the severity of the failure, waived, baselined, or an empty string if it passes
+func diffComparer(out io.Writer, r plan.ResourceChange, comparer compare.Comparer, waivers *compare.Waivers, known *baseline.Tracker) string {
+	diff, pass := comparer.Diff(r)
+	if pass {
+		return writeDiff(out, diff, true, "")
+	}
+
+	waiver, expired, ok := waivers.Lookup(r.GetAddress(), comparer.RuleID(r))
+	if ok {
+		diff = withNote(diff, describeWaiver(waiver, expired))
+		if !expired {
+			// waived failures are always written, so the exception stays visible
 			fmt.Fprintln(out, diff)
-			continue
+			return waived
 		}
+	}
+
+	return writeBaselineDiff(out, diff, resourceViolations(r, comparer), known, comparer.Severity(r))
+}
 
-		fmt.Fprintln(out, diff)
-		if errorOnFail {
-			exitCode = 1
+// writeBaselineDiff writes a failure with its violations, and returns its severity,
+// or baselined if every violation is in the baseline
+func writeBaselineDiff(out io.Writer, diff string, violations []baseline.Violation, known *baseline.Tracker, severity string) string {
+	if known == nil {
+		return writeDiff(out, diff, false, severity)
+	}
+
+	added := known.Match(violations)
+	if len(added) == 0 {
+		writeDiff(out, withNote(diff, describeBaselined()), true, "")
+		return baselined
+	}
+	return writeDiff(out, withNote(diff, describeNewViolations(added)), false, severity)
+}
+
+// runVariablesDiff writes the result of the variables rules, and returns the severity of the most severe failure
+func runVariablesDiff(out io.Writer, variables map[string]interface{}, comparer *compare.VariablesComparer) string {
+	diff, pass := comparer.Diff(variables)
+	severity := ""
+	if !pass {
+		severity = comparer.Severity(variables)
+	}
+	return writeDiff(out, diff, pass, severity)
+}
+
+// runConfigurationDiff writes the result of the configuration rules, which are always an error when they fail
+func runConfigurationDiff(out io.Writer, config *plan.Configuration, comparer *compare.ConfigurationComparer) string {
+	diff, pass := comparer.Diff(config)
+	return writeDiff(out, diff, pass, ruleset.SeverityError)
+}
+
+// writeDiff writes the result of a comparison, and returns the severity of the failure, or an empty string if it passed
+func writeDiff(out io.Writer, diff string, pass bool, severity string) string {
+	if pass {
+		if !failedOnly {
+			fmt.Fprintln(out, diff)
 		}
+		return ""
 	}
 
-	return exitCode
+	fmt.Fprintln(out, diff)
+	if severity == "" {
+		return ruleset.SeverityError
+	}
+	return severity
 }
@@ -9,14 +9,49 @@ import (
 	comparefakes "github.com/drlau/akashi/pkg/compare/fakes"
 	"github.com/drlau/akashi/pkg/plan"
 	planfakes "github.com/drlau/akashi/pkg/plan/fakes"
+	"github.com/drlau/akashi/pkg/ruleset"
 )
 
 func TestRunCompare(t *testing.T) {
 	cases := map[string]struct {
 		comparers      map[string]compare.Comparer
 		resourceChange []plan.ResourceChange
+		failOn         string
 		expected       int
 	}{
+		"warning rule fails with create resource": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					CompareReturns:  false,
+					SeverityReturns: ruleset.SeverityWarning,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns: true,
+					NameReturns:   "name",
+					TypeReturns:   "type",
+				},
+			},
+			expected: 0,
+		},
+		"warning rule fails with create resource and failOn warning": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					CompareReturns:  false,
+					SeverityReturns: ruleset.SeverityWarning,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns: true,
+					NameReturns:   "name",
+					TypeReturns:   "type",
+				},
+			},
+			failOn:   ruleset.SeverityWarning,
+			expected: 1,
+		},
 		"create returns false with create resource": {
 			comparers: map[string]compare.Comparer{
 				createKey: &comparefakes.FakeComparer{
@@ -104,12 +139,83 @@ func TestRunCompare(t *testing.T) {
 			},
 			expected: 1,
 		},
+		"read returns false with read resource": {
+			comparers: map[string]compare.Comparer{
+				readKey: &comparefakes.FakeComparer{
+					CompareReturns: false,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					ReadReturns: true,
+					NameReturns: "name",
+					TypeReturns: "type",
+				},
+			},
+			expected: 1,
+		},
+		"state returns false with existing resource": {
+			comparers: map[string]compare.Comparer{
+				stateKey: &comparefakes.FakeComparer{
+					CompareReturns: false,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					NoOpReturns: true,
+					NameReturns: "name",
+					TypeReturns: "type",
+				},
+			},
+			expected: 1,
+		},
+		"replacement fails the destroy comparer": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					CompareReturns: true,
+				},
+				destroyKey: &comparefakes.FakeComparer{
+					CompareReturns: false,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns: true,
+					DeleteReturns: true,
+					NameReturns:   "name",
+					TypeReturns:   "type",
+				},
+			},
+			expected: 1,
+		},
+		"replacement passes the create and destroy comparers": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					CompareReturns: true,
+				},
+				destroyKey: &comparefakes.FakeComparer{
+					CompareReturns: true,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns: true,
+					DeleteReturns: true,
+					NameReturns:   "name",
+					TypeReturns:   "type",
+				},
+			},
+			expected: 0,
+		},
 		// TODO: test case to ensure comparers are called correctly(matching type and number of calls)
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			if got := runCompare(tc.resourceChange, tc.comparers); got != tc.expected {
+			failOn = tc.failOn
+			defer func() { failOn = "" }()
+
+			if got := runCompare(tc.resourceChange, tc.comparers, nil, nil); got != tc.expected {
 				t.Errorf("Expected: %v but got %v", tc.expected, got)
 			}
 		})
@@ -312,6 +418,119 @@ func TestRunDiff(t *testing.T) {
 			expected:       0,
 			expectedOutput: []string{"comparer fail"},
 		},
+		"state comparer is used for existing resources": {
+			comparers: map[string]compare.Comparer{
+				stateKey: &comparefakes.FakeComparer{
+					DiffReturns: false,
+					DiffOutput:  "state fail",
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					NoOpReturns:    true,
+					AddressReturns: "address",
+					NameReturns:    "name",
+					TypeReturns:    "type",
+				},
+			},
+			preHook: func() {
+				errorOnFail = true
+			},
+			expected:       1,
+			expectedOutput: []string{"state fail"},
+		},
+		"warning rule fails with errorOnFail": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					DiffReturns:     false,
+					DiffOutput:      "comparer warning",
+					SeverityReturns: ruleset.SeverityWarning,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns:  true,
+					AddressReturns: "address",
+					NameReturns:    "name",
+					TypeReturns:    "type",
+				},
+			},
+			preHook: func() {
+				errorOnFail = true
+			},
+			expected:       0,
+			expectedOutput: []string{"comparer warning"},
+		},
+		"warning rule fails with failOn warning": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					DiffReturns:     false,
+					DiffOutput:      "comparer warning",
+					SeverityReturns: ruleset.SeverityWarning,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns:  true,
+					AddressReturns: "address",
+					NameReturns:    "name",
+					TypeReturns:    "type",
+				},
+			},
+			preHook: func() {
+				failOn = ruleset.SeverityWarning
+			},
+			expected:       1,
+			expectedOutput: []string{"comparer warning"},
+		},
+		"info rule fails with failOn warning": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					DiffReturns:     false,
+					DiffOutput:      "comparer info",
+					SeverityReturns: ruleset.SeverityInfo,
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns:  true,
+					AddressReturns: "address",
+					NameReturns:    "name",
+					TypeReturns:    "type",
+				},
+			},
+			preHook: func() {
+				failOn = ruleset.SeverityWarning
+			},
+			expected:       0,
+			expectedOutput: []string{"comparer info"},
+		},
+		"replacement writes the create and destroy comparers": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					DiffReturns: true,
+					DiffOutput:  "create pass",
+				},
+				destroyKey: &comparefakes.FakeComparer{
+					DiffReturns: false,
+					DiffOutput:  "destroy fail",
+				},
+			},
+			resourceChange: []plan.ResourceChange{
+				&planfakes.FakeResourceChange{
+					CreateReturns:  true,
+					DeleteReturns:  true,
+					AddressReturns: "address",
+					NameReturns:    "name",
+					TypeReturns:    "type",
+				},
+			},
+			preHook: func() {
+				errorOnFail = true
+			},
+			expected:       1,
+			expectedOutput: []string{"create pass", "destroy fail"},
+		},
 		// TODO: test case to ensure comparers are called correctly(matching type and number of calls)
 	}
 
@@ -319,6 +538,7 @@ func TestRunDiff(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			// set default vars
 			errorOnFail = false
+			failOn = ""
 			strict = false
 			failedOnly = false
 
@@ -327,7 +547,80 @@ func TestRunDiff(t *testing.T) {
 			}
 
 			var output bytes.Buffer
-			if got := runDiff(&output, tc.resourceChange, tc.comparers); got != tc.expected {
+			if got := runDiff(&output, tc.resourceChange, tc.comparers, nil, nil).exitCode(); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output.String(), s) {
+					t.Errorf("Result string did not contain %v", s)
+				}
+			}
+		})
+	}
+}
+
+func TestRunVariablesDiff(t *testing.T) {
+	comparer := compare.NewVariablesComparer(ruleset.VariableRules{
+		Rules: []ruleset.VariableRule{
+			{
+				ResourceRules: ruleset.ResourceRules{
+					Enforced: map[string]ruleset.EnforceChange{
+						"environment": {
+							Value: "prod",
+						},
+					},
+				},
+			},
+		},
+	})
+
+	cases := map[string]struct {
+		variables      map[string]interface{}
+		preHook        func()
+		expected       int
+		expectedOutput []string
+	}{
+		"passing variables": {
+			variables: map[string]interface{}{
+				"environment": "prod",
+			},
+			expected:       0,
+			expectedOutput: []string{"variables"},
+		},
+		"failing variables": {
+			variables: map[string]interface{}{
+				"environment": "dev",
+			},
+			expected:       0,
+			expectedOutput: []string{"variables", "environment"},
+		},
+		"failing variables with errorOnFail": {
+			variables: map[string]interface{}{
+				"environment": "dev",
+			},
+			preHook: func() {
+				errorOnFail = true
+			},
+			expected:       1,
+			expectedOutput: []string{"variables", "environment"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			errorOnFail = false
+			failOn = ""
+			failedOnly = false
+
+			if tc.preHook != nil {
+				tc.preHook()
+			}
+
+			var output bytes.Buffer
+			counts := make(failureCounts)
+			counts.add(runVariablesDiff(&output, tc.variables, comparer))
+			if got := counts.exitCode(); got != tc.expected {
 				t.Errorf("Expected: %v but got %v", tc.expected, got)
 			}
 
@@ -339,3 +632,208 @@ func TestRunDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPlanComparers(t *testing.T) {
+	enabled := true
+	created := &ruleset.CreateDeleteResourceChanges{
+		Resources: []ruleset.CreateDeleteResourceChange{
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{Type: "google_compute_instance"},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		ruleset    ruleset.Ruleset
+		format     plan.Format
+		jsonStream bool
+		err        string
+	}{
+		"bare type rules with JSON stream": {
+			ruleset:    ruleset.Ruleset{CreatedResources: created},
+			format:     plan.FormatJSONStream,
+			jsonStream: true,
+			err:        "cannot be evaluated",
+		},
+		"rules ignoring extra arguments with JSON stream": {
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Default:   &ruleset.CompareOptions{IgnoreExtraArgs: &enabled},
+					Resources: created.Resources,
+				},
+			},
+			format: plan.FormatJSONStream,
+		},
+		"autoFail rules with JSON stream": {
+			ruleset: ruleset.Ruleset{
+				DestroyedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "google_compute_instance"},
+							CompareOptions:     ruleset.CompareOptions{AutoFail: &enabled},
+						},
+					},
+				},
+			},
+			format: plan.FormatJSONStream,
+		},
+		"enforced rules with JSON stream": {
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ruleset.ResourceRules{
+								Enforced: map[string]ruleset.EnforceChange{"zone": {Value: "a"}},
+							},
+						},
+					},
+				},
+			},
+			format:     plan.FormatJSONStream,
+			jsonStream: true,
+			err:        "cannot be evaluated",
+		},
+		"enforced rules with detected JSON stream": {
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ruleset.ResourceRules{
+								Enforced: map[string]ruleset.EnforceChange{"zone": {Value: "a"}},
+							},
+						},
+					},
+				},
+			},
+			format: plan.FormatJSONStream,
+			err:    "terraform show -json",
+		},
+		"updated after rules with JSON stream": {
+			ruleset: ruleset.Ruleset{
+				UpdatedResources: &ruleset.UpdateResourceChanges{
+					Resources: []ruleset.UpdateResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "google_compute_instance"},
+							CompareOptions:     ruleset.CompareOptions{IgnoreExtraArgs: &enabled},
+							After:              &ruleset.ResourceRules{Ignored: []string{"labels"}},
+						},
+					},
+				},
+			},
+			format:     plan.FormatJSONStream,
+			jsonStream: true,
+			err:        "cannot be evaluated",
+		},
+		"default requireAll with JSON stream": {
+			ruleset: ruleset.Ruleset{
+				DestroyedResources: &ruleset.CreateDeleteResourceChanges{
+					Default:   &ruleset.CompareOptions{IgnoreExtraArgs: &enabled, RequireAll: &enabled},
+					Resources: created.Resources,
+				},
+			},
+			format:     plan.FormatJSONStream,
+			jsonStream: true,
+			err:        "cannot be evaluated",
+		},
+		"enforced rules with JSON plan": {
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "google_compute_instance"},
+							CompareOptions:     ruleset.CompareOptions{EnforceAll: &enabled},
+						},
+					},
+				},
+			},
+			format: plan.FormatJSONPlan,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			jsonStream = tc.jsonStream
+			defer func() { jsonStream = false }()
+
+			_, err := newPlanComparers(tc.ruleset, tc.format)
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("Expected an error but got none")
+				}
+				if !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Expected error to contain %q but got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckPlanFile(t *testing.T) {
+	cases := map[string]struct {
+		planFile string
+		paths    []string
+		err      bool
+	}{
+		"plan file only": {
+			planFile: "plan.tfplan",
+		},
+		"files only": {
+			paths: []string{"plan.json"},
+		},
+		"plan file with a file": {
+			planFile: "plan.tfplan",
+			paths:    []string{"plan.json"},
+			err:      true,
+		},
+		"plan file with several files": {
+			planFile: "plan.tfplan",
+			paths:    []string{"a.json", "b.json"},
+			err:      true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			planFile = tc.planFile
+			defer func() { planFile = "" }()
+
+			err := checkPlanFile(tc.paths)
+			if tc.err && err == nil {
+				t.Errorf("Expected an error but got none")
+			}
+			if !tc.err && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteDrift(t *testing.T) {
+	drift := []plan.ResourceChange{
+		&planfakes.FakeResourceChange{
+			UpdateReturns:  true,
+			AddressReturns: "google_storage_bucket.logs",
+		},
+		&planfakes.FakeResourceChange{
+			DeleteReturns:  true,
+			AddressReturns: "google_compute_instance.web",
+		},
+	}
+
+	var output bytes.Buffer
+	writeDrift(&output, drift)
+	for _, s := range []string{
+		"google_storage_bucket.logs was updated outside of the plan",
+		"google_compute_instance.web was deleted outside of the plan",
+	} {
+		if !strings.Contains(output.String(), s) {
+			t.Errorf("Result string did not contain %v, got %v", s, output.String())
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/drlau/akashi/pkg/baseline"
+	"github.com/drlau/akashi/pkg/compare"
+	"github.com/drlau/akashi/pkg/plan"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+var (
+	baselineOutput string
+
+	// knownViolations is the baseline set with --baseline, or nil if there is none
+	knownViolations *baseline.Baseline
+)
+
+func newBaselineCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Record known violations, so that only new violations fail a run",
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create <path to ruleset>...",
+		Short: "Record the current violations of the plans as a baseline for --baseline",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runBaselineCreate,
+	}
+	createCmd.Flags().StringArrayVarP(&files, "file", "f", nil, "read plan output from file, which can be a glob (can be repeated to record several plans)")
+	createCmd.Flags().StringVar(&planFile, "plan-file", "", "read a plan saved with 'terraform plan -out', decoding it with 'terraform show -json'")
+	createCmd.Flags().BoolVarP(&strict, "strict", "s", false, "record resources that do not match a comparer as violations")
+	createCmd.Flags().StringVarP(&baselineOutput, "output", "o", "", "write the baseline to a file instead of stdout")
+	addParameterFlags(createCmd)
+	cmd.AddCommand(createCmd)
+
+	return cmd
+}
+
+func runBaselineCreate(_ *cobra.Command, args []string) error {
+	rs, err := loadRuleset(args...)
+	if err != nil {
+		return err
+	}
+
+	paths, err := expandFiles(files)
+	if err != nil {
+		return err
+	}
+	if err := checkPlanFile(paths); err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		// read stdin, or --plan-file if it is set
+		paths = []string{""}
+	}
+
+	var violations []baseline.Violation
+	for _, path := range paths {
+		v, err := planViolations(rs, path)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, v...)
+	}
+	b := baseline.New(violations)
+
+	if baselineOutput == "" {
+		return b.Write(os.Stdout)
+	}
+	f, err := os.Create(baselineOutput)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "recorded %s in %s\n", pluralize(len(b.Violations), "violation", "violations"), baselineOutput)
+	return nil
+}
+
+// loadBaseline loads the baseline set with --baseline
+func loadBaseline() error {
+	if baselineFile == "" {
+		return nil
+	}
+
+	b, err := baseline.Load(baselineFile)
+	if err != nil {
+		return err
+	}
+	knownViolations = b
+	return nil
+}
+
+// planViolations returns the violations of the resources in the plan at path
+func planViolations(rs ruleset.Ruleset, path string) ([]baseline.Violation, error) {
+	src, err := openPlan(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.close()
+
+	c, err := newPlanComparers(rs, src.format)
+	if err != nil {
+		return nil, err
+	}
+	src.requireValues = rs.ComparesValues()
+
+	var result []baseline.Violation
+	_, err = src.decode(func(r plan.ResourceChange) error {
+		result = append(result, failingViolations(r, c.resources, c.waivers)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// failingViolations returns the violations of the resource for each of its comparers it fails and is not waived
+func failingViolations(r plan.ResourceChange, comparers map[string]compare.Comparer, waivers *compare.Waivers) []baseline.Violation {
+	matched := comparersFor(r, comparers)
+	if len(matched) == 0 {
+		if !strict {
+			return nil
+		}
+		return unmatchedViolations(r)
+	}
+
+	var result []baseline.Violation
+	for _, comparer := range matched {
+		if comparer.Compare(r) || waivers.Waives(r.GetAddress(), comparer.RuleID(r)) {
+			continue
+		}
+		result = append(result, resourceViolations(r, comparer)...)
+	}
+	return result
+}
+
+// resourceViolations returns a violation for each argument of the resource that fails the comparer
+func resourceViolations(r plan.ResourceChange, comparer compare.Comparer) []baseline.Violation {
+	return baseline.Violations(r.GetAddress(), comparer.RuleID(r), comparer.Failures(r))
+}
+
+// unmatchedViolations returns the violation of a resource with no matching comparer with --strict
+func unmatchedViolations(r plan.ResourceChange) []baseline.Violation {
+	return baseline.Violations(r.GetAddress(), "", nil)
+}
+
+// isBaselined returns true if every violation is in the baseline
+func isBaselined(violations []baseline.Violation, known *baseline.Tracker) bool {
+	return known != nil && len(known.Match(violations)) == 0
+}
+
+// describeNewViolations describes the violations of a failure that are not in the baseline
+func describeNewViolations(violations []baseline.Violation) string {
+	var attributes []string
+	for _, v := range violations {
+		if v.Attribute != "" {
+			attributes = append(attributes, v.Attribute)
+		}
+	}
+	if len(attributes) == 0 {
+		return utils.Red("New violation, not in the baseline")
+	}
+	return utils.Red(fmt.Sprintf("New violations, not in the baseline: %s", strings.Join(attributes, ", ")))
+}
+
+// describeBaselined describes a failure whose violations are all in the baseline
+func describeBaselined() string {
+	return utils.Cyan("Known violation, in the baseline")
+}
+
+// writeResolvedBaseline writes the entries of the baseline that no longer fail
+func writeResolvedBaseline(out io.Writer, violations []baseline.Violation) {
+	for _, v := range violations {
+		fmt.Fprintf(out, "%s baseline entry for %s is resolved\n", utils.Green("✓"), v)
+	}
+}
+
+// resolvedViolations returns the baseline entries resolved in any of the plans and seen in none of them
+func resolvedViolations(trackers []*baseline.Tracker) []baseline.Violation {
+	seen := make(map[string]bool)
+	for _, t := range trackers {
+		for _, v := range t.Seen() {
+			seen[v.Fingerprint] = true
+		}
+	}
+
+	var result []baseline.Violation
+	for _, t := range trackers {
+		for _, v := range t.Resolved() {
+			if seen[v.Fingerprint] {
+				continue
+			}
+			seen[v.Fingerprint] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
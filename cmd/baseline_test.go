@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/drlau/akashi/pkg/baseline"
+	"github.com/drlau/akashi/pkg/compare"
+	comparefakes "github.com/drlau/akashi/pkg/compare/fakes"
+	"github.com/drlau/akashi/pkg/plan"
+	planfakes "github.com/drlau/akashi/pkg/plan/fakes"
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestDiffResourceBaseline(t *testing.T) {
+	address := "google_compute_instance.web"
+	zone := baseline.NewViolation(address, "approved-zones", "zone")
+	machine := baseline.NewViolation(address, "approved-zones", "machine_type")
+	absent := baseline.NewViolation("google_compute_instance.api", "approved-zones", "zone")
+
+	cases := map[string]struct {
+		violations       []baseline.Violation
+		failures         []string
+		failedOnly       bool
+		expected         string
+		expectedPass     bool
+		expectedOutput   []string
+		expectedResolved []baseline.Violation
+	}{
+		"known violation": {
+			violations:     []baseline.Violation{zone},
+			failures:       []string{"zone"},
+			expected:       baselined,
+			expectedPass:   true,
+			expectedOutput: []string{"comparer fail", "Known violation, in the baseline"},
+		},
+		"known violation with failed only": {
+			violations:   []baseline.Violation{zone},
+			failures:     []string{"zone"},
+			failedOnly:   true,
+			expected:     baselined,
+			expectedPass: true,
+		},
+		"new violation": {
+			violations:     []baseline.Violation{zone},
+			failures:       []string{"machine_type", "zone"},
+			expected:       ruleset.SeverityError,
+			expectedPass:   false,
+			expectedOutput: []string{"comparer fail", "New violations, not in the baseline: machine_type"},
+		},
+		"new violation of the whole resource": {
+			violations:       []baseline.Violation{machine},
+			expected:         ruleset.SeverityError,
+			expectedPass:     false,
+			expectedOutput:   []string{"comparer fail", "New violation, not in the baseline"},
+			expectedResolved: []baseline.Violation{machine},
+		},
+		"known violation with an entry for an address absent from the plan": {
+			violations:     []baseline.Violation{zone, absent},
+			failures:       []string{"zone"},
+			expected:       baselined,
+			expectedPass:   true,
+			expectedOutput: []string{"comparer fail", "Known violation, in the baseline"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			failedOnly = tc.failedOnly
+			defer func() { failedOnly = false }()
+
+			comparers := map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{
+					CompareReturns:  false,
+					DiffReturns:     false,
+					DiffOutput:      "comparer fail",
+					RuleIDReturns:   "approved-zones",
+					FailuresReturns: tc.failures,
+				},
+			}
+			r := &planfakes.FakeResourceChange{
+				CreateReturns:  true,
+				AddressReturns: address,
+			}
+			known := baseline.NewTracker(baseline.New(tc.violations))
+
+			var output bytes.Buffer
+			if got := diffResource(&output, r, comparers, nil, known); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+			if len(tc.expectedOutput) == 0 && output.Len() != 0 {
+				t.Errorf("Expected no output but got %v", output.String())
+			}
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output.String(), s) {
+					t.Errorf("Result string did not contain %v, got %v", s, output.String())
+				}
+			}
+
+			if diff := cmp.Diff(tc.expectedResolved, known.Resolved()); diff != "" {
+				t.Errorf("Resolved mismatch (-expected +got):\n%s", diff)
+			}
+
+			if got := runCompare([]plan.ResourceChange{r}, comparers, nil, known) == 0; got != tc.expectedPass {
+				t.Errorf("Expected compare: %v but got %v", tc.expectedPass, got)
+			}
+		})
+	}
+}
+
+func TestFailingViolations(t *testing.T) {
+	address := "google_compute_instance.web"
+	failing := &comparefakes.FakeComparer{
+		CompareReturns:  false,
+		RuleIDReturns:   "approved-zones",
+		FailuresReturns: []string{"machine_type", "zone"},
+	}
+
+	cases := map[string]struct {
+		comparers map[string]compare.Comparer
+		waivers   []ruleset.Waiver
+		strict    bool
+		expected  []baseline.Violation
+	}{
+		"failing resource": {
+			comparers: map[string]compare.Comparer{createKey: failing},
+			expected: []baseline.Violation{
+				baseline.NewViolation(address, "approved-zones", "machine_type"),
+				baseline.NewViolation(address, "approved-zones", "zone"),
+			},
+		},
+		"passing resource": {
+			comparers: map[string]compare.Comparer{
+				createKey: &comparefakes.FakeComparer{CompareReturns: true},
+			},
+		},
+		"waived resource": {
+			comparers: map[string]compare.Comparer{createKey: failing},
+			waivers: []ruleset.Waiver{
+				{Address: address, Rule: "approved-zones", Expires: "2999-01-01"},
+			},
+		},
+		"no matching comparer": {
+			comparers: map[string]compare.Comparer{},
+		},
+		"no matching comparer with strict": {
+			comparers: map[string]compare.Comparer{},
+			strict:    true,
+			expected: []baseline.Violation{
+				baseline.NewViolation(address, "", ""),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			strict = tc.strict
+			defer func() { strict = false }()
+
+			r := &planfakes.FakeResourceChange{
+				CreateReturns:  true,
+				AddressReturns: address,
+			}
+			got := failingViolations(r, tc.comparers, compare.NewWaivers(tc.waivers, clock))
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Result mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolvedViolations(t *testing.T) {
+	first := baseline.NewViolation("module.a.google_compute_instance.web", "approved-zones", "zone")
+	second := baseline.NewViolation("module.b.google_compute_instance.web", "approved-zones", "zone")
+	b := baseline.New([]baseline.Violation{first, second})
+
+	// each plan has a different violation
+	a := baseline.NewTracker(b)
+	a.Match([]baseline.Violation{first})
+	c := baseline.NewTracker(b)
+	c.Match([]baseline.Violation{second})
+	if got := resolvedViolations([]*baseline.Tracker{a, c}); len(got) != 0 {
+		t.Errorf("Expected no resolved entries, got %v", got)
+	}
+
+	// a plan without the address does not resolve its entry
+	d := baseline.NewTracker(b)
+	d.Evaluate("module.c.google_compute_instance.web")
+	if got := resolvedViolations([]*baseline.Tracker{a, d}); len(got) != 0 {
+		t.Errorf("Expected no resolved entries, got %v", got)
+	}
+
+	d.Evaluate(second.Address)
+	if diff := cmp.Diff([]baseline.Violation{second}, resolvedViolations([]*baseline.Tracker{a, d})); diff != "" {
+		t.Errorf("Result mismatch (-expected +got):\n%s", diff)
+	}
+
+	var output bytes.Buffer
+	writeResolvedBaseline(&output, []baseline.Violation{second})
+	if !strings.Contains(output.String(), "baseline entry for module.b.google_compute_instance.web zone (rule approved-zones) is resolved") {
+		t.Errorf("Unexpected output %v", output.String())
+	}
+}
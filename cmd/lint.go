@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	encjson "encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+const (
+	lintOutputText = "text"
+	lintOutputJSON = "json"
+)
+
+var lintOutput string
+
+func newLintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint <path to ruleset>...",
+		Short: "Report duplicate, shadowed, contradictory and unreachable rules",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lintOutput != lintOutputText && lintOutput != lintOutputJSON {
+				return fmt.Errorf("invalid --output %q, must be %s or %s", lintOutput, lintOutputText, lintOutputJSON)
+			}
+
+			opts, err := loadOptions()
+			if err != nil {
+				return err
+			}
+			findings, err := ruleset.LintWithOptions(opts, args...)
+			if err != nil {
+				return err
+			}
+			out := utils.NewOutput(noColor)
+			os.Exit(runLint(out, findings))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&lintOutput, "output", "o", lintOutputText, "output format, either text or json")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable color output")
+	addParameterFlags(cmd)
+
+	return cmd
+}
+
+// runLint writes the findings and returns the exit code, which is 1 if any finding is an error
+func runLint(out io.Writer, findings []ruleset.LintFinding) int {
+	exitCode := 0
+	for _, f := range findings {
+		if f.Level == ruleset.LintError {
+			exitCode = 1
+		}
+	}
+
+	if lintOutput == lintOutputJSON {
+		if findings == nil {
+			findings = []ruleset.LintFinding{}
+		}
+		enc := encjson.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return exitCode
+	}
+
+	if len(findings) == 0 {
+		fmt.Fprintf(out, "%s no problems found\n", utils.Green("✓"))
+		return 0
+	}
+	for _, f := range findings {
+		symbol := utils.Yellow("!")
+		if f.Level == ruleset.LintError {
+			symbol = utils.Red("×")
+		}
+		fmt.Fprintf(out, "%s %s\n", symbol, f)
+	}
+	return exitCode
+}
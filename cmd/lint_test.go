@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestRunLint(t *testing.T) {
+	findings := []ruleset.LintFinding{
+		{Path: "ruleset.yaml", Line: 4, Level: ruleset.LintWarning, Check: "shadowed-rule", Message: "shadowed"},
+		{Path: "ruleset.yaml", Line: 8, Level: ruleset.LintError, Check: "duplicate-rule", Message: "duplicate"},
+	}
+
+	cases := map[string]struct {
+		findings       []ruleset.LintFinding
+		output         string
+		expected       int
+		expectedOutput []string
+	}{
+		"no findings": {
+			output:         lintOutputText,
+			expected:       0,
+			expectedOutput: []string{"no problems found"},
+		},
+		"only warnings": {
+			findings:       findings[:1],
+			output:         lintOutputText,
+			expected:       0,
+			expectedOutput: []string{"ruleset.yaml:4: warning: shadowed (shadowed-rule)"},
+		},
+		"errors": {
+			findings:       findings,
+			output:         lintOutputText,
+			expected:       1,
+			expectedOutput: []string{"ruleset.yaml:8: error: duplicate (duplicate-rule)"},
+		},
+		"json": {
+			findings:       findings,
+			output:         lintOutputJSON,
+			expected:       1,
+			expectedOutput: []string{`"check": "duplicate-rule"`, `"line": 8`},
+		},
+		"json without findings": {
+			output:         lintOutputJSON,
+			expected:       0,
+			expectedOutput: []string{"[]"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			lintOutput = tc.output
+
+			var output bytes.Buffer
+			if got := runLint(&output, tc.findings); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output.String(), s) {
+					t.Errorf("Result string did not contain %v, got %v", s, output.String())
+				}
+			}
+		})
+	}
+
+	lintOutput = lintOutputText
+}
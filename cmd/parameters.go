@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+var (
+	vars     []string
+	varFiles []string
+	env      string
+)
+
+// addParameterFlags adds the flags that set the parameters and environment rulesets are loaded with
+func addParameterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "set a ruleset parameter, written as name=value (can be repeated)")
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "read ruleset parameters from a YAML file (can be repeated, later files take priority)")
+	cmd.Flags().StringVar(&env, "env", "", "apply the overlays of the environment in the rulesets, such as prod")
+}
+
+// loadOptions returns the options rulesets are loaded with, from the parameter flags and environment variables
+func loadOptions() (ruleset.Options, error) {
+	opts := ruleset.Options{
+		Vars:      make(map[string]string),
+		VarFiles:  varFiles,
+		LookupEnv: os.LookupEnv,
+		Env:       env,
+	}
+	for _, v := range vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return opts, fmt.Errorf("invalid --var %q, must be written as name=value", v)
+		}
+		opts.Vars[parts[0]] = parts[1]
+	}
+	return opts, nil
+}
+
+// loadRuleset loads the rulesets at paths with the parameter flags
+func loadRuleset(paths ...string) (ruleset.Ruleset, error) {
+	opts, err := loadOptions()
+	if err != nil {
+		return ruleset.Ruleset{}, err
+	}
+	return ruleset.LoadWithOptions(opts, paths...)
+}
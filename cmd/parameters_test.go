@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadOptions(t *testing.T) {
+	cases := map[string]struct {
+		vars     []string
+		expected map[string]string
+		err      bool
+	}{
+		"no vars": {
+			expected: map[string]string{},
+		},
+		"vars": {
+			vars:     []string{"region=europe-west1", "filter=a=b", "empty="},
+			expected: map[string]string{"region": "europe-west1", "filter": "a=b", "empty": ""},
+		},
+		"missing value": {
+			vars: []string{"region"},
+			err:  true,
+		},
+		"missing name": {
+			vars: []string{"=europe-west1"},
+			err:  true,
+		},
+	}
+
+	defer func() { vars = nil }()
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			vars = tc.vars
+			opts, err := loadOptions()
+			if tc.err {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, opts.Vars); diff != "" {
+				t.Errorf("Vars mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/drlau/akashi/pkg/baseline"
+	"github.com/drlau/akashi/pkg/compare"
+	"github.com/drlau/akashi/pkg/plan"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+// planJob is a single plan to evaluate when evaluating several plans
+type planJob struct {
+	// label identifies the plan in the output, such as the path of the plan
+	label   string
+	ruleset ruleset.Ruleset
+	read    func() (*plan.Plan, plan.Format, error)
+}
+
+// planResult is the result of evaluating a single plan when evaluating several plans
+type planResult struct {
+	label  string
+	output bytes.Buffer
+	pass   bool
+	code   int
+	err    error
+
+	// waivers record which waivers matched a failure in the plan
+	waivers *compare.Waivers
+
+	// baseline records which baseline entries matched a violation in the plan
+	baseline *baseline.Tracker
+}
+
+// expandFiles expands the globs in the --file values, keeping the order they were given in
+func expandFiles(values []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		matches := []string{v}
+		if strings.ContainsAny(v, "*?[") {
+			var err error
+			matches, err = filepath.Glob(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %v", v, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no plans match %q", v)
+			}
+		}
+
+		for _, m := range matches {
+			if seen[m] {
+				continue
+			}
+			seen[m] = true
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
+// fileJobs returns a job for each plan file, evaluated against the same ruleset
+func fileJobs(rs ruleset.Ruleset, paths []string) []planJob {
+	var result []planJob
+	for _, path := range paths {
+		path := path
+		result = append(result, planJob{
+			label:   path,
+			ruleset: rs,
+			read: func() (*plan.Plan, plan.Format, error) {
+				return readPlan(path)
+			},
+		})
+	}
+	return result
+}
+
+// runPlans evaluates the plans with --parallelism workers, and writes their results in order followed by a summary
+func runPlans(out io.Writer, jobs []planJob) int {
+	results := make([]*planResult, len(jobs))
+	queue := make(chan int)
+
+	workers := parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = evaluatePlan(jobs[i])
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	return writePlanResults(out, results)
+}
+
+// evaluatePlan reads and evaluates a single plan, buffering its output
+func evaluatePlan(job planJob) *planResult {
+	result := &planResult{
+		label: job.label,
+	}
+
+	p, format, err := job.read()
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if p.ValuesOmitted != nil && job.ruleset.ComparesValues() {
+		result.err = valuesOmittedError(p.ValuesOmitted)
+		return result
+	}
+
+	c, err := newPlanComparers(job.ruleset, format)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	result.pass = c.compare(p)
+	if !quiet {
+		result.code = c.diff(&result.output, p)
+	}
+	result.waivers = c.waivers
+	result.baseline = c.baseline
+
+	return result
+}
+
+// writePlanResults writes the results of several plans and returns the combined exit code
+func writePlanResults(out io.Writer, results []*planResult) int {
+	exitCode := 0
+	var passed, failed, errored int
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			errored++
+			exitCode = 1
+		case r.pass:
+			passed++
+		default:
+			failed++
+			if quiet {
+				exitCode = 1
+			}
+		}
+		if r.code != 0 {
+			exitCode = r.code
+		}
+
+		if quiet {
+			continue
+		}
+		if r.err != nil {
+			fmt.Fprintln(out, utils.Bold(r.label))
+			fmt.Fprintln(out, utils.Red(fmt.Sprintf("× %v", r.err)))
+			fmt.Fprintln(out)
+			continue
+		}
+		if failedOnly && r.pass {
+			continue
+		}
+		fmt.Fprintln(out, utils.Bold(r.label))
+		out.Write(r.output.Bytes())
+		fmt.Fprintln(out)
+	}
+
+	if !quiet {
+		fmt.Fprintf(out, "%d plans: %d passed, %d failed, %d errored\n", len(results), passed, failed, errored)
+
+		// a waiver can apply to any of the plans, so it is only unused if it matched nothing in every plan
+		var waivers []*compare.Waivers
+		for _, r := range results {
+			if r.waivers != nil {
+				waivers = append(waivers, r.waivers)
+			}
+		}
+		writeUnusedWaivers(out, unusedWaivers(waivers))
+
+		// likewise a baseline entry is only resolved if it matched no violation in every plan
+		var trackers []*baseline.Tracker
+		for _, r := range results {
+			if r.baseline != nil {
+				trackers = append(trackers, r.baseline)
+			}
+		}
+		writeResolvedBaseline(out, resolvedViolations(trackers))
+	}
+
+	return exitCode
+}
@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	noChangesPlan = "No changes. Your infrastructure matches the configuration.\n"
+	createPlan    = `Terraform will perform the following actions:
+
+  # google_compute_instance.web will be created
+  + resource "google_compute_instance" "web" {
+      + name = "web"
+    }
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+`
+)
+
+func writeFiles(t *testing.T, contents map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "akashi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range contents {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpandFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a/plan.txt": noChangesPlan,
+		"b/plan.txt": noChangesPlan,
+		"c/plan.txt": noChangesPlan,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		values   []string
+		expected []string
+		err      bool
+	}{
+		"single file": {
+			values:   []string{filepath.Join(dir, "a/plan.txt")},
+			expected: []string{filepath.Join(dir, "a/plan.txt")},
+		},
+		"glob": {
+			values: []string{filepath.Join(dir, "*/plan.txt")},
+			expected: []string{
+				filepath.Join(dir, "a/plan.txt"),
+				filepath.Join(dir, "b/plan.txt"),
+				filepath.Join(dir, "c/plan.txt"),
+			},
+		},
+		"duplicates are removed": {
+			values: []string{filepath.Join(dir, "b/plan.txt"), filepath.Join(dir, "*/plan.txt")},
+			expected: []string{
+				filepath.Join(dir, "b/plan.txt"),
+				filepath.Join(dir, "a/plan.txt"),
+				filepath.Join(dir, "c/plan.txt"),
+			},
+		},
+		"missing file is kept": {
+			values:   []string{filepath.Join(dir, "missing.txt")},
+			expected: []string{filepath.Join(dir, "missing.txt")},
+		},
+		"glob without matches": {
+			values: []string{filepath.Join(dir, "*/missing.txt")},
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := expandFiles(tc.values)
+			if tc.err {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Files mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunPlans(t *testing.T) {
+	autoFail := true
+	dir := writeFiles(t, map[string]string{
+		"network/plan.txt":     noChangesPlan,
+		"compute/plan.txt":     createPlan,
+		"broken/plan.txt":      "Terraform will perform the following actions:\n",
+		"stack/changeset.json": `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Add","LogicalResourceId":"Bucket","ResourceType":"AWS::S3::Bucket"}}]}`,
+		"db/changeset.json":    `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Modify","LogicalResourceId":"DB","ResourceType":"AWS::RDS::DBInstance","Replacement":"True","BeforeContext":"{}","AfterContext":"{}"}}]}`,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		plans          []string
+		ruleset        ruleset.Ruleset
+		preHook        func()
+		expected       int
+		expectedOutput []string
+	}{
+		"passing plans": {
+			plans:          []string{"network/plan.txt", "compute/plan.txt"},
+			expected:       0,
+			expectedOutput: []string{"network/plan.txt", "compute/plan.txt", "2 plans: 2 passed, 0 failed, 0 errored"},
+		},
+		"failing plan": {
+			plans: []string{"network/plan.txt", "compute/plan.txt"},
+			preHook: func() {
+				strict = true
+			},
+			expected:       0,
+			expectedOutput: []string{"google_compute_instance.web (no matching comparer)", "2 plans: 1 passed, 1 failed, 0 errored"},
+		},
+		"failing plan with errorOnFail": {
+			plans: []string{"network/plan.txt", "compute/plan.txt"},
+			preHook: func() {
+				strict = true
+				errorOnFail = true
+			},
+			expected:       1,
+			expectedOutput: []string{"2 plans: 1 passed, 1 failed, 0 errored"},
+		},
+		"failing plan with quiet": {
+			plans: []string{"network/plan.txt", "compute/plan.txt"},
+			preHook: func() {
+				strict = true
+				quiet = true
+			},
+			expected: 1,
+		},
+		"unreadable plan": {
+			plans:          []string{"network/plan.txt", "broken/plan.txt"},
+			expected:       1,
+			expectedOutput: []string{"broken/plan.txt", "unexpected end of input", "2 plans: 1 passed, 0 failed, 1 errored"},
+		},
+		"change set without property values and rules that compare values": {
+			plans: []string{"network/plan.txt", "stack/changeset.json"},
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "AWS::S3::Bucket"},
+							ResourceRules: ruleset.ResourceRules{
+								Enforced: map[string]ruleset.EnforceChange{"BucketName": {Value: "logs"}},
+							},
+						},
+					},
+				},
+			},
+			expected:       1,
+			expectedOutput: []string{"stack/changeset.json", "--include-property-values", "2 plans: 1 passed, 0 failed, 1 errored"},
+		},
+		"change set without property values and a bare type rule": {
+			plans: []string{"network/plan.txt", "stack/changeset.json"},
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{ResourceIdentifier: ruleset.ResourceIdentifier{Type: "AWS::S3::Bucket"}},
+					},
+				},
+			},
+			expected:       1,
+			expectedOutput: []string{"stack/changeset.json", "--include-property-values", "2 plans: 1 passed, 0 failed, 1 errored"},
+		},
+		"replacement with created and destroyed resources rules": {
+			plans: []string{"network/plan.txt", "db/changeset.json"},
+			ruleset: ruleset.Ruleset{
+				CreatedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{ResourceIdentifier: ruleset.ResourceIdentifier{Type: "AWS::S3::Bucket"}},
+					},
+				},
+				DestroyedResources: &ruleset.CreateDeleteResourceChanges{
+					Resources: []ruleset.CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ruleset.ResourceIdentifier{Type: "AWS::RDS::DBInstance"},
+							CompareOptions:     ruleset.CompareOptions{AutoFail: &autoFail},
+						},
+					},
+				},
+			},
+			preHook: func() {
+				errorOnFail = true
+			},
+			expected:       1,
+			expectedOutput: []string{"DB (no matching rule)", "2 plans: 1 passed, 1 failed, 0 errored"},
+		},
+		"more plans than workers": {
+			plans: []string{"network/plan.txt", "compute/plan.txt", "network/plan.txt", "compute/plan.txt"},
+			preHook: func() {
+				parallelism = 1
+			},
+			expected:       0,
+			expectedOutput: []string{"4 plans: 4 passed, 0 failed, 0 errored"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			errorOnFail = false
+			strict = false
+			failedOnly = false
+			quiet = false
+			parallelism = 4
+
+			if tc.preHook != nil {
+				tc.preHook()
+			}
+
+			var paths []string
+			for _, p := range tc.plans {
+				paths = append(paths, filepath.Join(dir, p))
+			}
+
+			var output bytes.Buffer
+			if got := runPlans(&output, fileJobs(tc.ruleset, paths)); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output.String(), s) {
+					t.Errorf("Result string did not contain %v", s)
+				}
+			}
+			if quiet && output.Len() != 0 {
+				t.Errorf("Expected no output but got %q", output.String())
+			}
+		})
+	}
+
+	quiet = false
+	strict = false
+	errorOnFail = false
+}
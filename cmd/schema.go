@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the ruleset format",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSchema(os.Stdout)
+		},
+	}
+}
+
+// runSchema writes the JSON Schema of rulesets for this version of akashi
+func runSchema(out io.Writer) error {
+	schema, err := ruleset.JSONSchema(version)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", schema)
+	return err
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update the published schema")
+
+// TestRunSchema fails when the ruleset types and the published schema drift apart
+// Run with -update to regenerate the published schema
+func TestRunSchema(t *testing.T) {
+	path := filepath.Join("..", "schema", "ruleset.schema.json")
+
+	var output bytes.Buffer
+	if err := runSchema(&output); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if *update {
+		if err := ioutil.WriteFile(path, output.Bytes(), 0644); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(string(expected), output.String()); diff != "" {
+		t.Errorf("Schema is out of date, run go test ./cmd -update to regenerate it\n%s", diff)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+// failOnSeverities are the values --fail-on can be set to
+var failOnSeverities = []string{ruleset.SeverityWarning, ruleset.SeverityError}
+
+// validateFailOn returns an error if --fail-on is set to a severity that cannot fail a run
+func validateFailOn() error {
+	if failOn == "" {
+		return nil
+	}
+	for _, s := range failOnSeverities {
+		if failOn == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --fail-on %q, must be %s", failOn, strings.Join(failOnSeverities, " or "))
+}
+
+// failOnThreshold returns the least severe failure that fails a run
+func failOnThreshold() string {
+	if failOn == "" {
+		return ruleset.SeverityError
+	}
+	return failOn
+}
+
+// failsRun returns true if a failure of the severity fails a run
+func failsRun(severity string) bool {
+	return ruleset.SeverityAtLeast(severity, failOnThreshold())
+}
+
+const (
+	// waived counts failures that are waived
+	waived = "waived"
+	// baselined counts failures whose violations are all in the baseline
+	baselined = "baselined"
+)
+
+// moreSevere returns the more severe of two results of a resource, where a failure is more severe than waived or baselined,
+// and any result is more severe than an empty one
+func moreSevere(a, b string) string {
+	switch {
+	case b == "":
+		return a
+	case a == "", a == waived, a == baselined:
+		return b
+	case b == waived, b == baselined:
+		return a
+	case ruleset.SeverityAtLeast(a, b):
+		return a
+	}
+	return b
+}
+
+// failureCounts counts the failures of a plan by severity, and the failures that are waived or in the baseline
+type failureCounts map[string]int
+
+// add counts a failure of the severity, where an empty severity is not a failure
+func (c failureCounts) add(severity string) {
+	if severity != "" {
+		c[severity]++
+	}
+}
+
+func (c failureCounts) merge(other failureCounts) {
+	for k, v := range other {
+		c[k] += v
+	}
+}
+
+// exitCode returns 1 if a failure fails the run with --error-on-fail or --fail-on
+func (c failureCounts) exitCode() int {
+	if !errorOnFail && failOn == "" {
+		return 0
+	}
+	for severity, count := range c {
+		if severity != waived && severity != baselined && count > 0 && failsRun(severity) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// write writes the number of failures of each severity, if there are any
+func (c failureCounts) write(out io.Writer) {
+	if c[ruleset.SeverityError]+c[ruleset.SeverityWarning]+c[ruleset.SeverityInfo]+c[waived]+c[baselined] == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Failures: %s, %s, %s",
+		utils.Red(pluralize(c[ruleset.SeverityError], "error", "errors")),
+		utils.Yellow(pluralize(c[ruleset.SeverityWarning], "warning", "warnings")),
+		utils.Cyan(pluralize(c[ruleset.SeverityInfo], "info", "info")),
+	)
+	if c[waived] > 0 {
+		fmt.Fprintf(out, ", %d waived", c[waived])
+	}
+	if c[baselined] > 0 {
+		fmt.Fprintf(out, ", %d baselined", c[baselined])
+	}
+	fmt.Fprintln(out)
+}
+
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, singular)
+	}
+	return fmt.Sprintf("%d %s", count, plural)
+}
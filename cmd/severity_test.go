@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+// colorPattern matches the escape codes written around colored output
+var colorPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripColor(s string) string {
+	return colorPattern.ReplaceAllString(s, "")
+}
+
+func TestFailureCounts(t *testing.T) {
+	cases := map[string]struct {
+		severities     []string
+		errorOnFail    bool
+		failOn         string
+		expected       int
+		expectedOutput string
+	}{
+		"no failures": {
+			severities:     []string{"", ""},
+			errorOnFail:    true,
+			expected:       0,
+			expectedOutput: "",
+		},
+		"errors without errorOnFail": {
+			severities:     []string{ruleset.SeverityError},
+			expected:       0,
+			expectedOutput: "Failures: 1 error, 0 warnings, 0 info\n",
+		},
+		"errors with errorOnFail": {
+			severities:     []string{ruleset.SeverityError, ruleset.SeverityError, ruleset.SeverityWarning},
+			errorOnFail:    true,
+			expected:       1,
+			expectedOutput: "Failures: 2 errors, 1 warning, 0 info\n",
+		},
+		"warnings with errorOnFail": {
+			severities:     []string{ruleset.SeverityWarning, ruleset.SeverityInfo},
+			errorOnFail:    true,
+			expected:       0,
+			expectedOutput: "Failures: 0 errors, 1 warning, 1 info\n",
+		},
+		"warnings with failOn warning": {
+			severities:     []string{ruleset.SeverityWarning, ruleset.SeverityWarning},
+			failOn:         ruleset.SeverityWarning,
+			expected:       1,
+			expectedOutput: "Failures: 0 errors, 2 warnings, 0 info\n",
+		},
+		"info with failOn warning": {
+			severities:     []string{ruleset.SeverityInfo},
+			failOn:         ruleset.SeverityWarning,
+			expected:       0,
+			expectedOutput: "Failures: 0 errors, 0 warnings, 1 info\n",
+		},
+		"waived failures": {
+			severities:     []string{waived, ruleset.SeverityWarning},
+			errorOnFail:    true,
+			expected:       0,
+			expectedOutput: "Failures: 0 errors, 1 warning, 0 info, 1 waived\n",
+		},
+		"baselined failures": {
+			severities:     []string{baselined, baselined, waived},
+			errorOnFail:    true,
+			expected:       0,
+			expectedOutput: "Failures: 0 errors, 0 warnings, 0 info, 1 waived, 2 baselined\n",
+		},
+		"errors with failOn error": {
+			severities:     []string{ruleset.SeverityError},
+			failOn:         ruleset.SeverityError,
+			expected:       1,
+			expectedOutput: "Failures: 1 error, 0 warnings, 0 info\n",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			errorOnFail = tc.errorOnFail
+			failOn = tc.failOn
+			defer func() {
+				errorOnFail = false
+				failOn = ""
+			}()
+
+			counts := make(failureCounts)
+			for _, s := range tc.severities {
+				counts.add(s)
+			}
+			if got := counts.exitCode(); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			var output bytes.Buffer
+			counts.write(&output)
+			if got := stripColor(output.String()); got != tc.expectedOutput {
+				t.Errorf("Expected: %q but got %q", tc.expectedOutput, got)
+			}
+		})
+	}
+}
+
+func TestValidateFailOn(t *testing.T) {
+	for _, v := range []string{"", "warning", "error"} {
+		failOn = v
+		if err := validateFailOn(); err != nil {
+			t.Errorf("Unexpected error for %q: %v", v, err)
+		}
+	}
+
+	failOn = "info"
+	defer func() { failOn = "" }()
+	if err := validateFailOn(); err == nil {
+		t.Errorf("Expected an error but got none")
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/drlau/akashi/pkg/plan"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+// runTerragrunt evaluates each module in the output of "terragrunt run-all plan" as a separate plan
+func runTerragrunt(rs ruleset.Ruleset, paths []string) error {
+	if !terragrunt {
+		return fmt.Errorf("--terragrunt-ruleset requires --terragrunt")
+	}
+	if len(paths) > 1 {
+		return fmt.Errorf("--terragrunt reads a single output, but %d files were given", len(paths))
+	}
+	if planFile != "" {
+		return fmt.Errorf("--terragrunt cannot be used with --plan-file")
+	}
+	if format, err := requestedFormat(); err != nil || format != "" {
+		return fmt.Errorf("--terragrunt reads terraform plan output, and cannot be used with --json, --json-stream or --state")
+	}
+
+	rulesets, err := parseModuleRulesets(terragruntRulesets)
+	if err != nil {
+		return err
+	}
+
+	var data io.Reader = os.Stdin
+	if len(paths) == 1 {
+		f, err := os.Open(paths[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		data = f
+	}
+
+	jobs, err := terragruntJobs(data, rulesets, rs)
+	if err != nil {
+		return err
+	}
+
+	out := utils.NewOutput(noColor)
+	os.Exit(runPlans(out, jobs))
+	return nil
+}
+
+// moduleRuleset is a ruleset that applies to the terragrunt modules matching the pattern
+type moduleRuleset struct {
+	pattern string
+	ruleset ruleset.Ruleset
+}
+
+// parseModuleRulesets parses the --terragrunt-ruleset values, which are written as "pattern=path to ruleset"
+func parseModuleRulesets(values []string) ([]moduleRuleset, error) {
+	var result []moduleRuleset
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid --terragrunt-ruleset %q, expected pattern=path", v)
+		}
+		if _, err := path.Match(parts[0], ""); err != nil {
+			return nil, fmt.Errorf("invalid --terragrunt-ruleset pattern %q: %v", parts[0], err)
+		}
+
+		rs, err := loadRuleset(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, moduleRuleset{
+			pattern: parts[0],
+			ruleset: rs,
+		})
+	}
+	return result, nil
+}
+
+// rulesetForModule returns the ruleset of the first pattern that matches the module path or one of its parent directories
+func rulesetForModule(modulePath string, rulesets []moduleRuleset, defaultRuleset ruleset.Ruleset) ruleset.Ruleset {
+	for _, r := range rulesets {
+		for p := path.Clean(modulePath); p != "." && p != "/"; p = path.Dir(p) {
+			if ok, _ := path.Match(r.pattern, p); ok {
+				return r.ruleset
+			}
+		}
+	}
+	return defaultRuleset
+}
+
+// terragruntJobs splits the output of "terragrunt run-all plan" into a job for each module
+func terragruntJobs(in io.Reader, rulesets []moduleRuleset, defaultRuleset ruleset.Ruleset) ([]planJob, error) {
+	modules, err := plan.SplitTerragruntOutput(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no module output found, terragrunt output must be prefixed with the module path")
+	}
+
+	var result []planJob
+	for _, m := range modules {
+		m := m
+		result = append(result, planJob{
+			label:   m.Path,
+			ruleset: rulesetForModule(m.Path, rulesets, defaultRuleset),
+			read: func() (*plan.Plan, plan.Format, error) {
+				p, err := m.NewPlan()
+				return p, plan.FormatText, err
+			},
+		})
+	}
+	return result, nil
+}
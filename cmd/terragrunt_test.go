@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestRulesetForModule(t *testing.T) {
+	defaultRuleset := ruleset.Ruleset{}
+	prodRuleset := ruleset.Ruleset{CreatedResources: &ruleset.CreateDeleteResourceChanges{Strict: true}}
+	rulesets := []moduleRuleset{
+		{pattern: "live/prod", ruleset: prodRuleset},
+	}
+
+	cases := map[string]struct {
+		path     string
+		expected ruleset.Ruleset
+	}{
+		"matching path": {
+			path:     "live/prod",
+			expected: prodRuleset,
+		},
+		"matching parent directory": {
+			path:     "live/prod/vpc",
+			expected: prodRuleset,
+		},
+		"no match": {
+			path:     "live/dev/vpc",
+			expected: defaultRuleset,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := rulesetForModule(tc.path, rulesets, defaultRuleset)
+			if (got.CreatedResources != nil) != (tc.expected.CreatedResources != nil) {
+				t.Errorf("Expected the ruleset for %s to be %v but got %v", tc.path, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseModuleRulesets(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ruleset.yaml": "createdResources:\n  strict: true\n",
+	})
+	defer os.RemoveAll(dir)
+	rulesetPath := filepath.Join(dir, "ruleset.yaml")
+
+	cases := map[string]struct {
+		values []string
+		err    bool
+	}{
+		"valid": {
+			values: []string{"live/prod/*=" + rulesetPath},
+		},
+		"missing ruleset": {
+			values: []string{"live/prod/*"},
+			err:    true,
+		},
+		"invalid pattern": {
+			values: []string{"live/[prod=" + rulesetPath},
+			err:    true,
+		},
+		"unreadable ruleset": {
+			values: []string{"live/prod/*=missing.yaml"},
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseModuleRulesets(tc.values)
+			if tc.err && err == nil {
+				t.Errorf("Expected an error but got none")
+			}
+			if !tc.err && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTerragruntJobs(t *testing.T) {
+	f, err := os.Open("../pkg/plan/testdata/terragrunt-run-all.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// only the app module creates resources, so strict rules fail it
+	rulesets := []moduleRuleset{
+		{pattern: "live/prod/app", ruleset: ruleset.Ruleset{}},
+	}
+	jobs, err := terragruntJobs(f, rulesets, ruleset.Ruleset{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	errorOnFail = true
+	strict = true
+	failedOnly = false
+	quiet = false
+	defer func() {
+		errorOnFail = false
+		strict = false
+	}()
+
+	var output bytes.Buffer
+	if got := runPlans(&output, jobs); got != 1 {
+		t.Errorf("Expected: 1 but got %v", got)
+	}
+	for _, s := range []string{"live/prod/vpc", "live/prod/app", "google_storage_bucket.uploads (no matching comparer)", "2 plans: 1 passed, 1 failed, 0 errored"} {
+		if !strings.Contains(output.String(), s) {
+			t.Errorf("Result string did not contain %v", s)
+		}
+	}
+
+	if _, err := terragruntJobs(strings.NewReader("unprefixed output\n"), nil, ruleset.Ruleset{}); err == nil {
+		t.Errorf("Expected an error for output without module prefixes but got none")
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+func newValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate <path to ruleset>...",
+		Short: "Check rulesets for errors without evaluating a plan",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			out := utils.NewOutput(noColor)
+			os.Exit(runValidate(out, args))
+		},
+	}
+
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "disable color output")
+	addParameterFlags(cmd)
+
+	return cmd
+}
+
+// runValidate loads and merges the rulesets, writing every problem found, and returns the exit code
+func runValidate(out io.Writer, paths []string) int {
+	_, err := loadRuleset(paths...)
+	if err == nil {
+		fmt.Fprintf(out, "%s ruleset is valid\n", utils.Green("✓"))
+		return 0
+	}
+
+	if errs, ok := err.(ruleset.ValidationErrors); ok {
+		for _, e := range errs {
+			fmt.Fprintf(out, "%s %s\n", utils.Red("×"), e)
+		}
+		return 1
+	}
+	fmt.Fprintf(out, "%s %s\n", utils.Red("×"), err)
+	return 1
+}
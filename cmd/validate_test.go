@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunValidate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"valid.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        value: us-central1-a
+`,
+		"invalid.yaml": `
+createdResources:
+  default:
+    enforcedAll: true
+  resources:
+  - enforced:
+      zone:
+        value: us-central1-a
+        matchAny:
+        - us-east1-b
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		path           string
+		expected       int
+		expectedOutput []string
+	}{
+		"valid ruleset": {
+			path:           "valid.yaml",
+			expected:       0,
+			expectedOutput: []string{"ruleset is valid"},
+		},
+		"invalid ruleset": {
+			path:     "invalid.yaml",
+			expected: 1,
+			expectedOutput: []string{
+				"invalid.yaml:4: unknown field \"enforcedAll\"",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			if got := runValidate(&output, []string{filepath.Join(dir, tc.path)}); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output.String(), s) {
+					t.Errorf("Result string did not contain %v, got %v", s, output.String())
+				}
+			}
+		})
+	}
+}
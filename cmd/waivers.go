@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/drlau/akashi/pkg/compare"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+// clock is the time waivers expire against
+var clock = time.Now
+
+// describeWaiver describes the waiver of a failure, which no longer waives it once it has expired
+func describeWaiver(w ruleset.Waiver, expired bool) string {
+	if expired {
+		return utils.Red(fmt.Sprintf("Waiver expired on %s: %s (approved by %s)", w.Expires, w.Reason, w.ApprovedBy))
+	}
+	return utils.Cyan(fmt.Sprintf("Waived until %s: %s (approved by %s)", w.Expires, w.Reason, w.ApprovedBy))
+}
+
+// withNote adds a note, such as the description of a waiver, below the first line of the failure, which is the address
+func withNote(diff string, note string) string {
+	parts := strings.SplitN(diff, "\n", 2)
+	parts[0] += "\n" + note
+	return strings.Join(parts, "\n")
+}
+
+// writeUnusedWaivers writes the waivers that did not match any failure, so they can be removed
+func writeUnusedWaivers(out io.Writer, waivers []ruleset.Waiver) {
+	for _, w := range waivers {
+		fmt.Fprintf(out, "%s waiver for rule %s on %s did not match any failure\n", utils.Yellow("!"), w.Rule, w.Address)
+	}
+}
+
+// unusedWaivers returns the waivers that did not match a failure in any of the plans, in the order they are first seen
+func unusedWaivers(waivers []*compare.Waivers) []ruleset.Waiver {
+	used := make(map[ruleset.Waiver]bool)
+	for _, w := range waivers {
+		for _, u := range w.Used() {
+			used[u] = true
+		}
+	}
+
+	var result []ruleset.Waiver
+	seen := make(map[ruleset.Waiver]bool)
+	for _, w := range waivers {
+		for _, u := range w.Unused() {
+			if used[u] || seen[u] {
+				continue
+			}
+			seen[u] = true
+			result = append(result, u)
+		}
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/drlau/akashi/pkg/compare"
+	comparefakes "github.com/drlau/akashi/pkg/compare/fakes"
+	"github.com/drlau/akashi/pkg/plan"
+	planfakes "github.com/drlau/akashi/pkg/plan/fakes"
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestDiffResourceWaivers(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	}
+	waiver := ruleset.Waiver{
+		Address:    "module.legacy.*",
+		Rule:       "approved-zones",
+		Reason:     "migrating to the new zones",
+		ApprovedBy: "platform-team",
+		Expires:    "2026-11-01",
+	}
+	expiredWaiver := waiver
+	expiredWaiver.Expires = "2026-10-01"
+
+	comparers := map[string]compare.Comparer{
+		createKey: &comparefakes.FakeComparer{
+			CompareReturns: false,
+			DiffReturns:    false,
+			DiffOutput:     "comparer fail",
+			RuleIDReturns:  "approved-zones",
+		},
+	}
+
+	cases := map[string]struct {
+		waivers        []ruleset.Waiver
+		address        string
+		expected       string
+		expectedPass   bool
+		expectedOutput []string
+	}{
+		"waived failure": {
+			waivers:        []ruleset.Waiver{waiver},
+			address:        "module.legacy.google_compute_instance.web",
+			expected:       waived,
+			expectedPass:   true,
+			expectedOutput: []string{"comparer fail", "Waived until 2026-11-01: migrating to the new zones (approved by platform-team)"},
+		},
+		"expired waiver": {
+			waivers:        []ruleset.Waiver{expiredWaiver},
+			address:        "module.legacy.google_compute_instance.web",
+			expected:       ruleset.SeverityError,
+			expectedPass:   false,
+			expectedOutput: []string{"comparer fail", "Waiver expired on 2026-10-01"},
+		},
+		"no matching waiver": {
+			waivers:        []ruleset.Waiver{waiver},
+			address:        "google_compute_instance.web",
+			expected:       ruleset.SeverityError,
+			expectedPass:   false,
+			expectedOutput: []string{"comparer fail"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := &planfakes.FakeResourceChange{
+				CreateReturns:  true,
+				AddressReturns: tc.address,
+			}
+
+			var output bytes.Buffer
+			if got := diffResource(&output, r, comparers, compare.NewWaivers(tc.waivers, now), nil); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output.String(), s) {
+					t.Errorf("Result string did not contain %v, got %v", s, output.String())
+				}
+			}
+
+			if got := runCompare([]plan.ResourceChange{r}, comparers, compare.NewWaivers(tc.waivers, now), nil) == 0; got != tc.expectedPass {
+				t.Errorf("Expected compare: %v but got %v", tc.expectedPass, got)
+			}
+		})
+	}
+}
+
+func TestUnusedWaivers(t *testing.T) {
+	first := ruleset.Waiver{Address: "module.a.*", Rule: "approved-zones", Expires: "2026-11-01"}
+	second := ruleset.Waiver{Address: "module.b.*", Rule: "approved-zones", Expires: "2026-11-01"}
+
+	// each plan uses a different waiver
+	a := compare.NewWaivers([]ruleset.Waiver{first, second}, time.Now)
+	a.Lookup("module.a.google_compute_instance.web", "approved-zones")
+	b := compare.NewWaivers([]ruleset.Waiver{first, second}, time.Now)
+	b.Lookup("module.b.google_compute_instance.web", "approved-zones")
+	if got := unusedWaivers([]*compare.Waivers{a, b}); len(got) != 0 {
+		t.Errorf("Expected every waiver to be used, got %v", got)
+	}
+
+	c := compare.NewWaivers([]ruleset.Waiver{first, second}, time.Now)
+	if diff := cmp.Diff([]ruleset.Waiver{second}, unusedWaivers([]*compare.Waivers{a, c})); diff != "" {
+		t.Errorf("Result mismatch (-expected +got):\n%s", diff)
+	}
+
+	var output bytes.Buffer
+	writeUnusedWaivers(&output, []ruleset.Waiver{second})
+	if !strings.Contains(output.String(), "waiver for rule approved-zones on module.b.* did not match any failure") {
+		t.Errorf("Unexpected output %v", output.String())
+	}
+}
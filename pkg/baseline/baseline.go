@@ -0,0 +1,206 @@
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Violation is a single failing attribute of a resource, recorded by the rule it fails
+// Rule is empty for rules without an id, and Attribute is empty if the whole resource fails
+type Violation struct {
+	Fingerprint string `json:"fingerprint"`
+	Address     string `json:"address"`
+	Rule        string `json:"rule"`
+	Attribute   string `json:"attribute"`
+}
+
+// NewViolation returns the violation of the attribute for the address and rule
+func NewViolation(address, rule, attribute string) Violation {
+	return Violation{
+		Fingerprint: Fingerprint(address, rule, attribute),
+		Address:     address,
+		Rule:        rule,
+		Attribute:   attribute,
+	}
+}
+
+// Violations returns the violations of a failing resource, one for each failing attribute or one for the whole resource
+func Violations(address, rule string, attributes []string) []Violation {
+	if len(attributes) == 0 {
+		return []Violation{NewViolation(address, rule, "")}
+	}
+
+	result := make([]Violation, 0, len(attributes))
+	for _, a := range attributes {
+		result = append(result, NewViolation(address, rule, a))
+	}
+	return result
+}
+
+// Fingerprint identifies a violation by its address, rule and attribute
+func Fingerprint(address, rule, attribute string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{address, rule, attribute}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// String describes the violation for output
+func (v Violation) String() string {
+	result := v.Address
+	if v.Attribute != "" {
+		result += " " + v.Attribute
+	}
+	if v.Rule != "" {
+		result += fmt.Sprintf(" (rule %s)", v.Rule)
+	}
+	return result
+}
+
+// Baseline is a record of known violations, which do not fail a run
+type Baseline struct {
+	Violations []Violation `json:"violations"`
+}
+
+// New returns a baseline of the violations, sorted and without duplicates so it diffs cleanly
+func New(violations []Violation) *Baseline {
+	seen := make(map[string]bool)
+	result := make([]Violation, 0, len(violations))
+	for _, v := range violations {
+		if seen[v.Fingerprint] {
+			continue
+		}
+		seen[v.Fingerprint] = true
+		result = append(result, v)
+	}
+	sortViolations(result)
+
+	return &Baseline{
+		Violations: result,
+	}
+}
+
+// Load reads the baseline at path, and returns an error if a fingerprint does not match its entry
+func Load(path string) (*Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var b Baseline
+	if err := json.NewDecoder(f).Decode(&b); err != nil {
+		return nil, fmt.Errorf("%s: invalid baseline: %v", path, err)
+	}
+	for _, v := range b.Violations {
+		if expected := Fingerprint(v.Address, v.Rule, v.Attribute); v.Fingerprint != expected {
+			return nil, fmt.Errorf("%s: fingerprint of %s is %s, but should be %s", path, v, v.Fingerprint, expected)
+		}
+	}
+
+	return &b, nil
+}
+
+// Write writes the baseline as indented JSON
+func (b *Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// Tracker matches violations against a baseline, and records the entries seen and the addresses evaluated
+// A nil Tracker knows no violations
+type Tracker struct {
+	baseline  *Baseline
+	known     map[string]bool
+	seen      map[string]bool
+	evaluated map[string]bool
+}
+
+// NewTracker returns a tracker for the baseline, or nil if there is no baseline
+func NewTracker(b *Baseline) *Tracker {
+	if b == nil {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for _, v := range b.Violations {
+		known[v.Fingerprint] = true
+	}
+	return &Tracker{
+		baseline:  b,
+		known:     known,
+		seen:      make(map[string]bool),
+		evaluated: make(map[string]bool),
+	}
+}
+
+// Evaluate records that the resource at the address has been evaluated
+func (t *Tracker) Evaluate(address string) {
+	if t == nil {
+		return
+	}
+	t.evaluated[address] = true
+}
+
+// Match records the violations that are in the baseline as seen, and returns the ones that are not
+func (t *Tracker) Match(violations []Violation) []Violation {
+	if t == nil {
+		return violations
+	}
+
+	var result []Violation
+	for _, v := range violations {
+		if !t.known[v.Fingerprint] {
+			result = append(result, v)
+			continue
+		}
+		t.seen[v.Fingerprint] = true
+	}
+	return result
+}
+
+// Seen returns the entries of the baseline that have matched a violation
+func (t *Tracker) Seen() []Violation {
+	return t.filter(func(v Violation) bool {
+		return t.seen[v.Fingerprint]
+	})
+}
+
+// Resolved returns the entries of the baseline for evaluated addresses that have not matched any violation
+func (t *Tracker) Resolved() []Violation {
+	return t.filter(func(v Violation) bool {
+		return t.evaluated[v.Address] && !t.seen[v.Fingerprint]
+	})
+}
+
+func (t *Tracker) filter(keep func(Violation) bool) []Violation {
+	if t == nil {
+		return nil
+	}
+
+	var result []Violation
+	for _, v := range t.baseline.Violations {
+		if keep(v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func sortViolations(violations []Violation) {
+	sort.Slice(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Address != b.Address {
+			return a.Address < b.Address
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Attribute < b.Attribute
+	})
+}
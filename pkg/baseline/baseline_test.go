@@ -0,0 +1,187 @@
+package baseline
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestViolations(t *testing.T) {
+	cases := map[string]struct {
+		attributes []string
+		expected   []Violation
+	}{
+		"failing attributes": {
+			attributes: []string{"machine_type", "zone"},
+			expected: []Violation{
+				NewViolation("google_compute_instance.web", "approved-zones", "machine_type"),
+				NewViolation("google_compute_instance.web", "approved-zones", "zone"),
+			},
+		},
+		"whole resource": {
+			expected: []Violation{
+				NewViolation("google_compute_instance.web", "approved-zones", ""),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, Violations("google_compute_instance.web", "approved-zones", tc.attributes)); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	cases := map[string]struct {
+		a []string
+		b []string
+	}{
+		"different address": {
+			a: []string{"a.b", "rule", "zone"},
+			b: []string{"a.c", "rule", "zone"},
+		},
+		"different rule": {
+			a: []string{"a.b", "rule", "zone"},
+			b: []string{"a.b", "other", "zone"},
+		},
+		"different attribute": {
+			a: []string{"a.b", "rule", "zone"},
+			b: []string{"a.b", "rule", "name"},
+		},
+		"fields are not concatenated": {
+			a: []string{"a.b", "rule", ""},
+			b: []string{"a.b", "", "rule"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := Fingerprint(tc.a[0], tc.a[1], tc.a[2])
+			b := Fingerprint(tc.b[0], tc.b[1], tc.b[2])
+			if a == b {
+				t.Errorf("Expected different fingerprints but got %v for both", a)
+			}
+			if a != Fingerprint(tc.a[0], tc.a[1], tc.a[2]) {
+				t.Errorf("Expected the same fingerprint for the same violation")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	zone := NewViolation("google_compute_instance.web", "approved-zones", "zone")
+	machine := NewViolation("google_compute_instance.web", "approved-types", "machine_type")
+	other := NewViolation("google_compute_instance.api", "approved-zones", "zone")
+
+	expected := &Baseline{
+		Violations: []Violation{other, machine, zone},
+	}
+	if diff := cmp.Diff(expected, New([]Violation{zone, machine, other, zone})); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "baseline")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	zone := NewViolation("google_compute_instance.web", "approved-zones", "zone")
+	tampered := zone
+	tampered.Address = "google_compute_instance.api"
+
+	cases := map[string]struct {
+		content     string
+		baseline    *Baseline
+		expected    *Baseline
+		expectError bool
+	}{
+		"written baseline": {
+			baseline: New([]Violation{zone}),
+			expected: New([]Violation{zone}),
+		},
+		"fingerprint does not match": {
+			baseline:    &Baseline{Violations: []Violation{tampered}},
+			expectError: true,
+		},
+		"invalid json": {
+			content:     "violations:",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".json")
+			content := []byte(tc.content)
+			if tc.baseline != nil {
+				var buf bytes.Buffer
+				if err := tc.baseline.Write(&buf); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				content = buf.Bytes()
+			}
+			if err := ioutil.WriteFile(path, content, 0644); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got, err := Load(path)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	zone := NewViolation("google_compute_instance.web", "approved-zones", "zone")
+	machine := NewViolation("google_compute_instance.web", "approved-types", "machine_type")
+	fixed := NewViolation("google_compute_instance.api", "approved-zones", "zone")
+	added := NewViolation("google_compute_instance.db", "approved-zones", "zone")
+	absent := NewViolation("google_compute_instance.cache", "approved-zones", "zone")
+
+	tracker := NewTracker(New([]Violation{zone, machine, fixed, absent}))
+	tracker.Evaluate("google_compute_instance.web")
+	tracker.Evaluate("google_compute_instance.api")
+	tracker.Evaluate("google_compute_instance.db")
+	if diff := cmp.Diff([]Violation{added}, tracker.Match([]Violation{zone, added})); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+	if got := tracker.Match([]Violation{machine}); got != nil {
+		t.Errorf("Expected: %v but got %v", nil, got)
+	}
+	// the entry of the address that was not evaluated is neither seen nor resolved
+	if diff := cmp.Diff([]Violation{machine, zone}, tracker.Seen()); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]Violation{fixed}, tracker.Resolved()); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+
+	var none *Tracker
+	none.Evaluate("google_compute_instance.web")
+	if diff := cmp.Diff([]Violation{zone}, none.Match([]Violation{zone})); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+	if got := none.Resolved(); got != nil {
+		t.Errorf("Expected: %v but got %v", nil, got)
+	}
+}
@@ -7,4 +7,13 @@ import (
 type Comparer interface {
 	Compare(plan.ResourceChange) bool
 	Diff(plan.ResourceChange) (string, bool)
+
+	// Severity returns how a failure of the resource change is treated
+	Severity(plan.ResourceChange) string
+
+	// RuleID returns the id of the rule for the resource change, which waivers refer to
+	RuleID(plan.ResourceChange) string
+
+	// Failures returns the arguments that fail the rule for the resource change
+	Failures(plan.ResourceChange) []string
 }
@@ -0,0 +1,181 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drlau/akashi/pkg/plan"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+const configurationAddress = "configuration"
+
+// ConfigurationComparer compares the configuration a plan was made from
+type ConfigurationComparer struct {
+	ToolVersion  string
+	Tool         string
+	ToolVersions map[string]string
+
+	Providers *ruleset.ProviderRules
+	Modules   *ruleset.ModuleRules
+}
+
+func NewConfigurationComparer(ruleset ruleset.ConfigurationRules) *ConfigurationComparer {
+	return &ConfigurationComparer{
+		ToolVersion:  ruleset.ToolVersion,
+		Tool:         ruleset.Tool,
+		ToolVersions: ruleset.ToolVersions,
+		Providers:    ruleset.Providers,
+		Modules:      ruleset.Modules,
+	}
+}
+
+func (c *ConfigurationComparer) Compare(config *plan.Configuration) bool {
+	return len(c.violations(config)) == 0
+}
+
+func (c *ConfigurationComparer) Diff(config *plan.Configuration) (string, bool) {
+	violations := c.violations(config)
+	if len(violations) == 0 {
+		return fmt.Sprintf("%s %s", utils.Green("✓"), configurationAddress), true
+	}
+
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("%s %s\n", utils.Red("×"), utils.Red(configurationAddress)))
+	for _, v := range violations {
+		buf.WriteString(utils.Red(fmt.Sprintf("  - %s\n", v)))
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n"), false
+}
+
+func (c *ConfigurationComparer) violations(config *plan.Configuration) []string {
+	var result []string
+	if config == nil {
+		config = &plan.Configuration{}
+	}
+
+	if c.ToolVersion != "" {
+		ok, err := utils.CheckVersionConstraint(config.ToolVersion, c.ToolVersion)
+		if err != nil {
+			result = append(result, fmt.Sprintf("tool version %q: %v", config.ToolVersion, err))
+		} else if !ok {
+			result = append(result, fmt.Sprintf("tool version %s does not satisfy %q", config.ToolVersion, c.ToolVersion))
+		}
+	}
+
+	if c.Tool != "" && c.Tool != config.Tool {
+		tool := config.Tool
+		if tool == "" {
+			tool = "an unknown tool"
+		}
+		result = append(result, fmt.Sprintf("plan was made with %s, but %s is required", tool, c.Tool))
+	}
+
+	if constraint, ok := c.ToolVersions[config.Tool]; ok && config.Tool != "" {
+		ok, err := utils.CheckVersionConstraint(config.ToolVersion, constraint)
+		if err != nil {
+			result = append(result, fmt.Sprintf("%s version %q: %v", config.Tool, config.ToolVersion, err))
+		} else if !ok {
+			result = append(result, fmt.Sprintf("%s version %s does not satisfy %q", config.Tool, config.ToolVersion, constraint))
+		}
+	}
+
+	if c.Providers != nil {
+		allowed := make(map[string]bool)
+		for _, p := range c.Providers.Allowed {
+			allowed[p] = true
+		}
+		for _, p := range config.Providers {
+			name := providerKey(p)
+			if len(allowed) > 0 && !allowed[name] {
+				result = append(result, fmt.Sprintf("%s: provider is not allowed", providerAddress(p)))
+			}
+			if c.Providers.RequireVersion && p.VersionConstraint == "" {
+				result = append(result, fmt.Sprintf("%s: provider has no version constraint", providerAddress(p)))
+			}
+		}
+	}
+
+	if c.Modules != nil {
+		for _, m := range config.ModuleCalls {
+			if len(c.Modules.AllowedSources) > 0 && !hasAnyPrefix(m.Source, c.Modules.AllowedSources) {
+				result = append(result, fmt.Sprintf("%s: source %s is not allowed", m.Address, m.Source))
+			}
+
+			local := isLocalModuleSource(m.Source)
+			if c.Modules.DenyLocal && local {
+				result = append(result, fmt.Sprintf("%s: source %s is a local path", m.Address, m.Source))
+			}
+			if local {
+				continue
+			}
+
+			registry := isRegistryModuleSource(m.Source)
+			if c.Modules.RequireVersion {
+				if registry && m.VersionConstraint == "" {
+					result = append(result, fmt.Sprintf("%s: module has no version constraint", m.Address))
+				} else if !registry && !strings.Contains(m.Source, "ref=") {
+					result = append(result, fmt.Sprintf("%s: source %s has no ref", m.Address, m.Source))
+				}
+			}
+			if c.Modules.RequirePinnedVersion && registry && !utils.IsExactVersionConstraint(m.VersionConstraint) {
+				result = append(result, fmt.Sprintf("%s: module version %q is not pinned", m.Address, m.VersionConstraint))
+			}
+		}
+	}
+
+	return result
+}
+
+// providerKey returns the provider name in the form used by ProviderRules.Allowed
+func providerKey(p plan.ProviderConfig) string {
+	if p.Alias != "" {
+		return fmt.Sprintf("%s.%s", p.Name, p.Alias)
+	}
+	return p.Name
+}
+
+func providerAddress(p plan.ProviderConfig) string {
+	address := fmt.Sprintf("provider.%s", providerKey(p))
+	if p.ModuleAddress != "" {
+		return fmt.Sprintf("%s.%s", p.ModuleAddress, address)
+	}
+	return address
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// isRegistryModuleSource returns true if the source is a module registry address
+// Example: hashicorp/consul/aws or app.terraform.io/example-corp/k8s-cluster/azurerm
+func isRegistryModuleSource(source string) bool {
+	if strings.Contains(source, "://") || strings.Contains(source, "::") || strings.Contains(source, "?") || strings.HasPrefix(source, "git@") {
+		return false
+	}
+	// strip the sub directory
+	if i := strings.Index(source, "//"); i != -1 {
+		source = source[:i]
+	}
+
+	parts := strings.Split(source, "/")
+	switch len(parts) {
+	case 3:
+		return !strings.Contains(parts[0], ".")
+	case 4:
+		// github.com and bitbucket.org shorthands are not registry addresses
+		return strings.Contains(parts[0], ".") && parts[0] != "github.com" && parts[0] != "bitbucket.org"
+	}
+	return false
+}
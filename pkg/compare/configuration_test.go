@@ -0,0 +1,313 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/drlau/akashi/pkg/plan"
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestConfigurationCompare(t *testing.T) {
+	cases := map[string]struct {
+		ruleset  ruleset.ConfigurationRules
+		config   *plan.Configuration
+		expected bool
+	}{
+		"tool version satisfies constraint": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersion: ">= 0.12, < 0.14",
+			},
+			config: &plan.Configuration{
+				ToolVersion: "0.12.29",
+			},
+			expected: true,
+		},
+		"tool version does not satisfy constraint": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersion: ">= 0.12, < 0.14",
+			},
+			config: &plan.Configuration{
+				ToolVersion: "0.14.0",
+			},
+			expected: false,
+		},
+		"missing tool version": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersion: ">= 0.12",
+			},
+			config:   nil,
+			expected: false,
+		},
+		"required tool": {
+			ruleset: ruleset.ConfigurationRules{
+				Tool: plan.ToolOpenTofu,
+			},
+			config: &plan.Configuration{
+				Tool: plan.ToolOpenTofu,
+			},
+			expected: true,
+		},
+		"wrong tool": {
+			ruleset: ruleset.ConfigurationRules{
+				Tool: plan.ToolOpenTofu,
+			},
+			config: &plan.Configuration{
+				Tool: plan.ToolTerraform,
+			},
+			expected: false,
+		},
+		"tool version satisfies constraint for the tool": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersions: map[string]string{
+					plan.ToolTerraform: ">= 1.5",
+					plan.ToolOpenTofu:  ">= 1.6",
+				},
+			},
+			config: &plan.Configuration{
+				ToolVersion: "1.6.2",
+				Tool:        plan.ToolOpenTofu,
+			},
+			expected: true,
+		},
+		"tool version does not satisfy constraint for the tool": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersions: map[string]string{
+					plan.ToolTerraform: ">= 1.5",
+					plan.ToolOpenTofu:  ">= 1.7",
+				},
+			},
+			config: &plan.Configuration{
+				ToolVersion: "1.6.2",
+				Tool:        plan.ToolOpenTofu,
+			},
+			expected: false,
+		},
+		"tool version constraint for another tool": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersions: map[string]string{
+					plan.ToolOpenTofu: ">= 1.7",
+				},
+			},
+			config: &plan.Configuration{
+				ToolVersion: "1.5.7",
+				Tool:        plan.ToolTerraform,
+			},
+			expected: true,
+		},
+		"allowed providers": {
+			ruleset: ruleset.ConfigurationRules{
+				Providers: &ruleset.ProviderRules{
+					Allowed: []string{"google", "google.west"},
+				},
+			},
+			config: &plan.Configuration{
+				Providers: []plan.ProviderConfig{
+					{Name: "google"},
+					{Name: "google", Alias: "west"},
+				},
+			},
+			expected: true,
+		},
+		"disallowed provider alias": {
+			ruleset: ruleset.ConfigurationRules{
+				Providers: &ruleset.ProviderRules{
+					Allowed: []string{"google"},
+				},
+			},
+			config: &plan.Configuration{
+				Providers: []plan.ProviderConfig{
+					{Name: "google", Alias: "west"},
+				},
+			},
+			expected: false,
+		},
+		"provider without version constraint": {
+			ruleset: ruleset.ConfigurationRules{
+				Providers: &ruleset.ProviderRules{
+					RequireVersion: true,
+				},
+			},
+			config: &plan.Configuration{
+				Providers: []plan.ProviderConfig{
+					{Name: "google"},
+				},
+			},
+			expected: false,
+		},
+		"allowed module source": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					AllowedSources: []string{"app.terraform.io/acme/"},
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "app.terraform.io/acme/network/google"},
+				},
+			},
+			expected: true,
+		},
+		"disallowed module source": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					AllowedSources: []string{"app.terraform.io/acme/"},
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "terraform-google-modules/network/google"},
+				},
+			},
+			expected: false,
+		},
+		"local module source denied": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					DenyLocal: true,
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "../modules/network"},
+				},
+			},
+			expected: false,
+		},
+		"local module source ignores version requirements": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					RequireVersion:       true,
+					RequirePinnedVersion: true,
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "./network"},
+				},
+			},
+			expected: true,
+		},
+		"registry module without version": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					RequireVersion: true,
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "terraform-google-modules/network/google//modules/subnets"},
+				},
+			},
+			expected: false,
+		},
+		"git module with ref": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					RequireVersion:       true,
+					RequirePinnedVersion: true,
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "git::https://example.com/network.git?ref=v1.2.0"},
+				},
+			},
+			expected: true,
+		},
+		"registry module with range": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					RequirePinnedVersion: true,
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "terraform-google-modules/network/google", VersionConstraint: "~> 2.5"},
+				},
+			},
+			expected: false,
+		},
+		"registry module with pinned version": {
+			ruleset: ruleset.ConfigurationRules{
+				Modules: &ruleset.ModuleRules{
+					RequireVersion:       true,
+					RequirePinnedVersion: true,
+				},
+			},
+			config: &plan.Configuration{
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "terraform-google-modules/network/google", VersionConstraint: "2.5.0"},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := NewConfigurationComparer(tc.ruleset).Compare(tc.config); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestConfigurationDiff(t *testing.T) {
+	cases := map[string]struct {
+		ruleset        ruleset.ConfigurationRules
+		config         *plan.Configuration
+		expected       bool
+		expectedOutput []string
+	}{
+		"passing configuration": {
+			ruleset: ruleset.ConfigurationRules{
+				ToolVersion: ">= 0.12",
+			},
+			config: &plan.Configuration{
+				ToolVersion: "0.12.29",
+			},
+			expected:       true,
+			expectedOutput: []string{"✓", "configuration"},
+		},
+		"failing configuration": {
+			ruleset: ruleset.ConfigurationRules{
+				Providers: &ruleset.ProviderRules{
+					Allowed: []string{"google"},
+				},
+				Modules: &ruleset.ModuleRules{
+					DenyLocal: true,
+				},
+			},
+			config: &plan.Configuration{
+				Providers: []plan.ProviderConfig{
+					{Name: "aws", ModuleAddress: "module.network"},
+				},
+				ModuleCalls: []plan.ModuleCall{
+					{Address: "module.network", Source: "./network"},
+				},
+			},
+			expected: false,
+			expectedOutput: []string{
+				"×",
+				"module.network.provider.aws: provider is not allowed",
+				"module.network: source ./network is a local path",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			output, got := NewConfigurationComparer(tc.ruleset).Diff(tc.config)
+			if got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output, s) {
+					t.Errorf("Result string did not contain %v", s)
+				}
+			}
+		})
+	}
+}
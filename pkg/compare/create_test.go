@@ -7,16 +7,18 @@ import (
 	"github.com/drlau/akashi/pkg/plan"
 	planfakes "github.com/drlau/akashi/pkg/plan/fakes"
 	resourcefakes "github.com/drlau/akashi/pkg/resource/fakes"
+	"github.com/drlau/akashi/pkg/ruleset"
 )
 
 func TestCreateCompare(t *testing.T) {
 	cases := map[string]struct {
-		comparer       *CreateComparer
+		comparer       *CreateDeleteComparer
 		resourceChange plan.ResourceChange
 		expected       bool
 	}{
 		"matching nametype resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -32,7 +34,8 @@ func TestCreateCompare(t *testing.T) {
 			expected: true,
 		},
 		"matching nametype resource returning false": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -48,7 +51,8 @@ func TestCreateCompare(t *testing.T) {
 			expected: false,
 		},
 		"matching name resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameResources: map[string]resourceWithOpts{
 					"name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -64,7 +68,8 @@ func TestCreateCompare(t *testing.T) {
 			expected: true,
 		},
 		"matching type resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				TypeResources: map[string]resourceWithOpts{
 					"type": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -80,7 +85,8 @@ func TestCreateCompare(t *testing.T) {
 			expected: true,
 		},
 		"prioritizes matching nametype resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -110,7 +116,7 @@ func TestCreateCompare(t *testing.T) {
 			expected: true,
 		},
 		"no matching resource": {
-			comparer: &CreateComparer{},
+			comparer: &CreateDeleteComparer{values: afterValues},
 			resourceChange: &planfakes.FakeResourceChange{
 				NameReturns: "name",
 				TypeReturns: "type",
@@ -118,7 +124,8 @@ func TestCreateCompare(t *testing.T) {
 			expected: true,
 		},
 		"no matching resource with strict enabled": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				Strict: true,
 			},
 			resourceChange: &planfakes.FakeResourceChange{
@@ -140,13 +147,14 @@ func TestCreateCompare(t *testing.T) {
 
 func TestCreateDiff(t *testing.T) {
 	cases := map[string]struct {
-		comparer       *CreateComparer
+		comparer       *CreateDeleteComparer
 		resourceChange plan.ResourceChange
 		expected       bool
 		expectedOutput []string
 	}{
 		"matching nametype resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -164,7 +172,8 @@ func TestCreateDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"matching nametype resource returning false": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -181,8 +190,57 @@ func TestCreateDiff(t *testing.T) {
 			expected:       false,
 			expectedOutput: []string{"×", "address"},
 		},
+		"failing resource with metadata": {
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
+				NameTypeResources: map[string]resourceWithOpts{
+					"type.name": resourceWithOpts{
+						resource: &resourcefakes.FakeResource{
+							DiffReturns: "failed",
+						},
+						meta: ruleset.RuleMetadata{
+							ID:          "approved-zones",
+							Description: "instances must be in an approved zone",
+							Severity:    ruleset.SeverityWarning,
+							Owner:       "platform-team",
+							Docs:        "docs/approved-zones.md",
+						},
+					},
+				},
+			},
+			resourceChange: &planfakes.FakeResourceChange{
+				AddressReturns: "address",
+				NameReturns:    "name",
+				TypeReturns:    "type",
+			},
+			expected:       false,
+			expectedOutput: []string{"×", "address", "approved-zones", "instances must be in an approved zone", "Severity:", "warning", "Owner:", "platform-team", "docs/approved-zones.md", "failed"},
+		},
+		"passing resource with metadata": {
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
+				NameTypeResources: map[string]resourceWithOpts{
+					"type.name": resourceWithOpts{
+						resource: &resourcefakes.FakeResource{
+							DiffReturns: "",
+						},
+						meta: ruleset.RuleMetadata{
+							ID: "approved-zones",
+						},
+					},
+				},
+			},
+			resourceChange: &planfakes.FakeResourceChange{
+				AddressReturns: "address",
+				NameReturns:    "name",
+				TypeReturns:    "type",
+			},
+			expected:       true,
+			expectedOutput: []string{"✓", "address"},
+		},
 		"matching name resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameResources: map[string]resourceWithOpts{
 					"name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -200,7 +258,8 @@ func TestCreateDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"matching type resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				TypeResources: map[string]resourceWithOpts{
 					"type": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -218,7 +277,8 @@ func TestCreateDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"prioritizes matching nametype resource": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -250,7 +310,7 @@ func TestCreateDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"no matching resource": {
-			comparer: &CreateComparer{},
+			comparer: &CreateDeleteComparer{values: afterValues},
 			resourceChange: &planfakes.FakeResourceChange{
 				AddressReturns: "address",
 				NameReturns:    "name",
@@ -260,7 +320,8 @@ func TestCreateDiff(t *testing.T) {
 			expectedOutput: []string{"!", "address (no matching rule)"},
 		},
 		"no matching resource with strict enabled": {
-			comparer: &CreateComparer{
+			comparer: &CreateDeleteComparer{
+				values: afterValues,
 				Strict: true,
 			},
 			resourceChange: &planfakes.FakeResourceChange{
@@ -287,3 +348,104 @@ func TestCreateDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateSeverity(t *testing.T) {
+	comparer := NewCreateComparer(ruleset.CreateDeleteResourceChanges{
+		Resources: []ruleset.CreateDeleteResourceChange{
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{
+					Type: "type",
+				},
+				RuleMetadata: ruleset.RuleMetadata{
+					Severity: ruleset.SeverityWarning,
+				},
+			},
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{
+					Name: "name",
+				},
+			},
+		},
+	})
+
+	cases := map[string]struct {
+		resourceChange plan.ResourceChange
+		expected       string
+	}{
+		"rule with severity": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "other",
+				TypeReturns: "type",
+			},
+			expected: ruleset.SeverityWarning,
+		},
+		"rule without severity": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "name",
+				TypeReturns: "type",
+			},
+			expected: ruleset.SeverityError,
+		},
+		"no matching rule": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "other",
+				TypeReturns: "other",
+			},
+			expected: ruleset.SeverityError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := comparer.Severity(tc.resourceChange); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCreateFailures(t *testing.T) {
+	comparer := &CreateDeleteComparer{
+		values: afterValues,
+		TypeResources: map[string]resourceWithOpts{
+			"type": resourceWithOpts{
+				resource: &resourcefakes.FakeResource{
+					FailuresReturns: []string{"key", "other"},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		resourceChange plan.ResourceChange
+		expected       []string
+	}{
+		"matching rule": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "name",
+				TypeReturns: "type",
+			},
+			expected: []string{"key", "other"},
+		},
+		"no matching rule": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "name",
+				TypeReturns: "other",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := comparer.Failures(tc.resourceChange)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("Expected: %v but got %v", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("Expected: %v but got %v", tc.expected, got)
+				}
+			}
+		})
+	}
+}
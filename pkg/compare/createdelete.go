@@ -0,0 +1,116 @@
+package compare
+
+import (
+	"fmt"
+
+	"github.com/drlau/akashi/pkg/plan"
+	"github.com/drlau/akashi/pkg/resource"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+// CreateDeleteComparer compares resource changes against create or delete rules
+// The rules are compared against the values returned by values, such as the values after the change for created resources
+type CreateDeleteComparer struct {
+	Strict bool
+
+	NameResources     map[string]resourceWithOpts
+	TypeResources     map[string]resourceWithOpts
+	NameTypeResources map[string]resourceWithOpts
+
+	values func(plan.ResourceChange) resource.ResourceValues
+}
+
+func newCreateDeleteComparer(ruleset ruleset.CreateDeleteResourceChanges, values func(plan.ResourceChange) resource.ResourceValues) *CreateDeleteComparer {
+	defaultOptions := makeDefaultCompareOptions(ruleset.Default)
+	nameTypeResources := make(map[string]resourceWithOpts)
+	typeResources := make(map[string]resourceWithOpts)
+	nameResources := make(map[string]resourceWithOpts)
+
+	// Iterate over all the resources
+	for _, r := range ruleset.Resources {
+		if r.Name != "" && r.Type != "" {
+			// format name and type key
+			// construct Resource and add to map
+			nameTypeResources[constructModeKey(r.Mode, fmt.Sprintf("%s.%s", r.Type, r.Name))] = newCreateDeleteResourceWithOpts(r, defaultOptions)
+		} else if r.Name != "" {
+			// construct resource and add to name map
+			nameResources[constructModeKey(r.Mode, r.Name)] = newCreateDeleteResourceWithOpts(r, defaultOptions)
+		} else if r.Type != "" {
+			// construct type and add to type map
+			typeResources[constructModeKey(r.Mode, r.Type)] = newCreateDeleteResourceWithOpts(r, defaultOptions)
+		}
+	}
+	return &CreateDeleteComparer{
+		Strict:            ruleset.Strict,
+		NameResources:     nameResources,
+		TypeResources:     typeResources,
+		NameTypeResources: nameTypeResources,
+		values:            values,
+	}
+}
+
+// afterValues returns the values of a resource after the change, along with the values known after apply
+func afterValues(r plan.ResourceChange) resource.ResourceValues {
+	return resource.ResourceValues{
+		Values:   r.GetAfter(),
+		Computed: r.GetComputed(),
+	}
+}
+
+// beforeValues returns the values of a resource before the change
+func beforeValues(r plan.ResourceChange) resource.ResourceValues {
+	return resource.ResourceValues{
+		Values: r.GetBefore(),
+	}
+}
+
+func (c *CreateDeleteComparer) Compare(r plan.ResourceChange) bool {
+	if ro, ok := lookupRule(c.NameTypeResources, c.NameResources, c.TypeResources, r); ok {
+		return ro.compare(c.values(r))
+	}
+
+	return !c.Strict
+}
+
+func (c *CreateDeleteComparer) Diff(r plan.ResourceChange) (string, bool) {
+	ro, ok := lookupRule(c.NameTypeResources, c.NameResources, c.TypeResources, r)
+	if !ok {
+		if c.Strict {
+			return fmt.Sprintf("%s %s (no matching rule)", utils.Red("×"), r.GetAddress()), false
+		}
+
+		return fmt.Sprintf("%s %s (no matching rule)", utils.Yellow("!"), r.GetAddress()), true
+	}
+
+	diff := ro.diff(c.values(r))
+	if diff != "" {
+		color := ro.color()
+		return fmt.Sprintf("%s %s\n%s", color("×"), color(r.GetAddress()), diff), false
+	}
+
+	return fmt.Sprintf("%s %s", utils.Green("✓"), r.GetAddress()), true
+}
+
+// Severity returns how a failure of the resource change is treated
+func (c *CreateDeleteComparer) Severity(r plan.ResourceChange) string {
+	if ro, ok := lookupRule(c.NameTypeResources, c.NameResources, c.TypeResources, r); ok {
+		return ro.severity()
+	}
+	return ruleset.SeverityError
+}
+
+// RuleID returns the id of the rule for the resource change, or an empty string if it has none
+func (c *CreateDeleteComparer) RuleID(r plan.ResourceChange) string {
+	ro, _ := lookupRule(c.NameTypeResources, c.NameResources, c.TypeResources, r)
+	return ro.meta.ID
+}
+
+// Failures returns the arguments that fail the rule for the resource change
+func (c *CreateDeleteComparer) Failures(r plan.ResourceChange) []string {
+	ro, ok := lookupRule(c.NameTypeResources, c.NameResources, c.TypeResources, r)
+	if !ok {
+		return nil
+	}
+	return ro.failures(c.values(r))
+}
@@ -11,12 +11,13 @@ import (
 
 func TestDestroyCompare(t *testing.T) {
 	cases := map[string]struct {
-		comparer       *DestroyComparer
+		comparer       *CreateDeleteComparer
 		resourceChange plan.ResourceChange
 		expected       bool
 	}{
 		"matching nametype resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -32,7 +33,8 @@ func TestDestroyCompare(t *testing.T) {
 			expected: true,
 		},
 		"matching nametype resource returning false": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -48,7 +50,8 @@ func TestDestroyCompare(t *testing.T) {
 			expected: false,
 		},
 		"matching name resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameResources: map[string]resourceWithOpts{
 					"name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -64,7 +67,8 @@ func TestDestroyCompare(t *testing.T) {
 			expected: true,
 		},
 		"matching type resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				TypeResources: map[string]resourceWithOpts{
 					"type": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -80,7 +84,8 @@ func TestDestroyCompare(t *testing.T) {
 			expected: true,
 		},
 		"prioritizes matching nametype resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -110,7 +115,7 @@ func TestDestroyCompare(t *testing.T) {
 			expected: true,
 		},
 		"no matching resource": {
-			comparer: &DestroyComparer{},
+			comparer: &CreateDeleteComparer{values: beforeValues},
 			resourceChange: &planfakes.FakeResourceChange{
 				NameReturns: "name",
 				TypeReturns: "type",
@@ -118,7 +123,8 @@ func TestDestroyCompare(t *testing.T) {
 			expected: true,
 		},
 		"no matching resource with strict enabled": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				Strict: true,
 			},
 			resourceChange: &planfakes.FakeResourceChange{
@@ -140,13 +146,14 @@ func TestDestroyCompare(t *testing.T) {
 
 func TestDestroyDiff(t *testing.T) {
 	cases := map[string]struct {
-		comparer       *DestroyComparer
+		comparer       *CreateDeleteComparer
 		resourceChange plan.ResourceChange
 		expected       bool
 		expectedOutput []string
 	}{
 		"matching nametype resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -164,7 +171,8 @@ func TestDestroyDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"matching nametype resource returning false": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -182,7 +190,8 @@ func TestDestroyDiff(t *testing.T) {
 			expectedOutput: []string{"×", "address"},
 		},
 		"matching name resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameResources: map[string]resourceWithOpts{
 					"name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -200,7 +209,8 @@ func TestDestroyDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"matching type resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				TypeResources: map[string]resourceWithOpts{
 					"type": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -218,7 +228,8 @@ func TestDestroyDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"prioritizes matching nametype resource": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				NameTypeResources: map[string]resourceWithOpts{
 					"type.name": resourceWithOpts{
 						resource: &resourcefakes.FakeResource{
@@ -250,7 +261,7 @@ func TestDestroyDiff(t *testing.T) {
 			expectedOutput: []string{""},
 		},
 		"no matching resource": {
-			comparer: &DestroyComparer{},
+			comparer: &CreateDeleteComparer{values: beforeValues},
 			resourceChange: &planfakes.FakeResourceChange{
 				AddressReturns: "address",
 				NameReturns:    "name",
@@ -260,7 +271,8 @@ func TestDestroyDiff(t *testing.T) {
 			expectedOutput: []string{"!", "address (no matching rule)"},
 		},
 		"no matching resource with strict enabled": {
-			comparer: &DestroyComparer{
+			comparer: &CreateDeleteComparer{
+				values: beforeValues,
 				Strict: true,
 			},
 			resourceChange: &planfakes.FakeResourceChange{
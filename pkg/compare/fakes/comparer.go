@@ -5,9 +5,12 @@ import (
 )
 
 type FakeComparer struct {
-	CompareReturns bool
-	DiffReturns    bool
-	DiffOutput     string
+	CompareReturns  bool
+	DiffReturns     bool
+	DiffOutput      string
+	SeverityReturns string
+	RuleIDReturns   string
+	FailuresReturns []string
 }
 
 func (r *FakeComparer) Compare(rc plan.ResourceChange) bool {
@@ -17,3 +20,15 @@ func (r *FakeComparer) Compare(rc plan.ResourceChange) bool {
 func (r *FakeComparer) Diff(rc plan.ResourceChange) (string, bool) {
 	return r.DiffOutput, r.DiffReturns
 }
+
+func (r *FakeComparer) Severity(rc plan.ResourceChange) string {
+	return r.SeverityReturns
+}
+
+func (r *FakeComparer) RuleID(rc plan.ResourceChange) string {
+	return r.RuleIDReturns
+}
+
+func (r *FakeComparer) Failures(rc plan.ResourceChange) []string {
+	return r.FailuresReturns
+}
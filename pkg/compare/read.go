@@ -0,0 +1,15 @@
+package compare
+
+import (
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+// ReadComparer is the comparer returned by NewReadComparer
+//
+// Deprecated: use CreateDeleteComparer
+type ReadComparer = CreateDeleteComparer
+
+// NewReadComparer returns a comparer for data sources that are read during the plan or apply
+func NewReadComparer(ruleset ruleset.CreateDeleteResourceChanges) *CreateDeleteComparer {
+	return newCreateDeleteComparer(ruleset, afterValues)
+}
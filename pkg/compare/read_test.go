@@ -0,0 +1,84 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/drlau/akashi/pkg/plan"
+	planfakes "github.com/drlau/akashi/pkg/plan/fakes"
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestReadCompare(t *testing.T) {
+	comparer := NewReadComparer(ruleset.CreateDeleteResourceChanges{
+		Strict: true,
+		Resources: []ruleset.CreateDeleteResourceChange{
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{
+					Type: "type",
+					Mode: "data",
+				},
+				ResourceRules: ruleset.ResourceRules{
+					Enforced: map[string]ruleset.EnforceChange{
+						"zone": {Value: "a"},
+					},
+				},
+			},
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{
+					Type: "type",
+				},
+				ResourceRules: ruleset.ResourceRules{
+					Enforced: map[string]ruleset.EnforceChange{
+						"zone": {Value: "b"},
+					},
+				},
+			},
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{
+					Type: "managed_only",
+					Mode: "managed",
+				},
+			},
+		},
+	})
+
+	cases := map[string]struct {
+		resourceChange plan.ResourceChange
+		expected       bool
+	}{
+		"prioritizes matching mode resource": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns:  "name",
+				TypeReturns:  "type",
+				ModeReturns:  "data",
+				AfterReturns: map[string]interface{}{"zone": "a"},
+			},
+			expected: true,
+		},
+		"compares the values read": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns:  "name",
+				TypeReturns:  "type",
+				ModeReturns:  "data",
+				AfterReturns: map[string]interface{}{"zone": "b"},
+			},
+			expected: false,
+		},
+		"ignores resource for a different mode": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "name",
+				TypeReturns: "managed_only",
+				ModeReturns: "data",
+			},
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := comparer.Compare(tc.resourceChange); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
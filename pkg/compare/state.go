@@ -0,0 +1,15 @@
+package compare
+
+import (
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+// StateComparer is the comparer returned by NewStateComparer
+//
+// Deprecated: use CreateDeleteComparer
+type StateComparer = CreateDeleteComparer
+
+// NewStateComparer returns a comparer for resources that already exist, such as the resources in a state file
+func NewStateComparer(ruleset ruleset.CreateDeleteResourceChanges) *CreateDeleteComparer {
+	return newCreateDeleteComparer(ruleset, afterValues)
+}
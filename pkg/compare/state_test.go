@@ -0,0 +1,68 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/drlau/akashi/pkg/plan"
+	planfakes "github.com/drlau/akashi/pkg/plan/fakes"
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestStateCompare(t *testing.T) {
+	rules := ruleset.CreateDeleteResourceChanges{
+		Resources: []ruleset.CreateDeleteResourceChange{
+			{
+				ResourceIdentifier: ruleset.ResourceIdentifier{
+					Type: "type",
+				},
+				ResourceRules: ruleset.ResourceRules{
+					Enforced: map[string]ruleset.EnforceChange{
+						"zone": {Value: "a"},
+					},
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		strict         bool
+		resourceChange plan.ResourceChange
+		expected       bool
+	}{
+		"compares the current values": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns:   "name",
+				TypeReturns:   "type",
+				BeforeReturns: map[string]interface{}{"zone": "b"},
+				AfterReturns:  map[string]interface{}{"zone": "a"},
+			},
+			expected: true,
+		},
+		"current values failing the rule": {
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns:  "name",
+				TypeReturns:  "type",
+				AfterReturns: map[string]interface{}{"zone": "b"},
+			},
+			expected: false,
+		},
+		"no matching resource with strict enabled": {
+			strict: true,
+			resourceChange: &planfakes.FakeResourceChange{
+				NameReturns: "name",
+				TypeReturns: "other",
+			},
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rules.Strict = tc.strict
+			comparer := NewStateComparer(rules)
+			if got := comparer.Compare(tc.resourceChange); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package compare
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/drlau/akashi/pkg/plan"
@@ -23,6 +24,17 @@ type updateResource struct {
 	After  *resourceWithOpts
 }
 
+// rule returns the before or after rule, which are made from the same rule and have the same metadata
+func (ur updateResource) rule() resourceWithOpts {
+	if ur.Before != nil {
+		return *ur.Before
+	}
+	if ur.After != nil {
+		return *ur.After
+	}
+	return resourceWithOpts{}
+}
+
 func NewUpdateComparer(ruleset ruleset.UpdateResourceChanges) *UpdateComparer {
 	defaultOptions := makeDefaultCompareOptions(ruleset.Default)
 	nameTypeResources := make(map[string]updateResource)
@@ -33,24 +45,24 @@ func NewUpdateComparer(ruleset ruleset.UpdateResourceChanges) *UpdateComparer {
 	for _, r := range ruleset.Resources {
 		var ur updateResource
 		if r.Before != nil {
-			ro := newResourceWithOpts(r.ResourceIdentifier, *r.Before, r.CompareOptions, defaultOptions)
+			ro := newResourceWithOpts(r.ResourceIdentifier, *r.Before, r.CompareOptions, r.RuleMetadata, defaultOptions)
 			ur.Before = &ro
 		}
 		if r.After != nil {
-			ro := newResourceWithOpts(r.ResourceIdentifier, *r.After, r.CompareOptions, defaultOptions)
+			ro := newResourceWithOpts(r.ResourceIdentifier, *r.After, r.CompareOptions, r.RuleMetadata, defaultOptions)
 			ur.After = &ro
 		}
 
 		if r.Name != "" && r.Type != "" {
 			// format name and type key
 			// construct Resource and add to map
-			nameTypeResources[fmt.Sprintf("%s.%s", r.Type, r.Name)] = ur
+			nameTypeResources[constructModeKey(r.Mode, fmt.Sprintf("%s.%s", r.Type, r.Name))] = ur
 		} else if r.Name != "" {
 			// construct resource and add to name map
-			nameResources[r.Name] = ur
+			nameResources[constructModeKey(r.Mode, r.Name)] = ur
 		} else if r.Type != "" {
 			// construct type and add to type map
-			typeResources[r.Type] = ur
+			typeResources[constructModeKey(r.Mode, r.Type)] = ur
 		}
 	}
 	return &UpdateComparer{
@@ -77,7 +89,7 @@ func (c *UpdateComparer) Compare(r plan.ResourceChange) bool {
 		beforeOk = true
 		afterOk  = true
 	)
-	if ro, ok := c.NameTypeResources[nameType]; ok {
+	if ro, ok := lookupUpdateResource(c.NameTypeResources, r, nameType); ok {
 		if ro.Before != nil {
 			beforeOk = ro.Before.compare(beforeChanges)
 		}
@@ -85,7 +97,7 @@ func (c *UpdateComparer) Compare(r plan.ResourceChange) bool {
 			afterOk = ro.After.compare(afterChanges)
 		}
 		return beforeOk && afterOk
-	} else if ro, ok := c.NameResources[r.GetName()]; ok {
+	} else if ro, ok := lookupUpdateResource(c.NameResources, r, r.GetName()); ok {
 		if ro.Before != nil {
 			beforeOk = ro.Before.compare(beforeChanges)
 		}
@@ -93,7 +105,7 @@ func (c *UpdateComparer) Compare(r plan.ResourceChange) bool {
 			afterOk = ro.After.compare(afterChanges)
 		}
 		return beforeOk && afterOk
-	} else if ro, ok := c.TypeResources[r.GetType()]; ok {
+	} else if ro, ok := lookupUpdateResource(c.TypeResources, r, r.GetType()); ok {
 		if ro.Before != nil {
 			beforeOk = ro.Before.compare(beforeChanges)
 		}
@@ -120,11 +132,11 @@ func (c *UpdateComparer) Diff(r plan.ResourceChange) (string, bool) {
 	}
 
 	var ur updateResource
-	if rs, ok := c.NameTypeResources[nameType]; ok {
+	if rs, ok := lookupUpdateResource(c.NameTypeResources, r, nameType); ok {
 		ur = rs
-	} else if rs, ok := c.NameResources[r.GetName()]; ok {
+	} else if rs, ok := lookupUpdateResource(c.NameResources, r, r.GetName()); ok {
 		ur = rs
-	} else if rs, ok := c.TypeResources[r.GetType()]; ok {
+	} else if rs, ok := lookupUpdateResource(c.TypeResources, r, r.GetType()); ok {
 		ur = rs
 	} else {
 		if c.Strict {
@@ -143,7 +155,8 @@ func (c *UpdateComparer) Diff(r plan.ResourceChange) (string, bool) {
 		diff := ur.Before.diff(beforeChanges)
 		if diff != "" {
 			equal = false
-			result.WriteString(fmt.Sprintf("%s %s %s\n%s\n", utils.Red("×"), utils.Red(r.GetAddress()), utils.Red("(before)"), diff))
+			color := ur.Before.color()
+			result.WriteString(fmt.Sprintf("%s %s %s\n%s\n", color("×"), color(r.GetAddress()), color("(before)"), diff))
 		}
 	}
 
@@ -151,7 +164,8 @@ func (c *UpdateComparer) Diff(r plan.ResourceChange) (string, bool) {
 		diff := ur.After.diff(afterChanges)
 		if diff != "" {
 			equal = false
-			result.WriteString(fmt.Sprintf("%s %s %s\n%s\n", utils.Red("×"), utils.Red(r.GetAddress()), utils.Red("(after)"), diff))
+			color := ur.After.color()
+			result.WriteString(fmt.Sprintf("%s %s %s\n%s\n", color("×"), color(r.GetAddress()), color("(after)"), diff))
 		}
 	}
 
@@ -161,3 +175,60 @@ func (c *UpdateComparer) Diff(r plan.ResourceChange) (string, bool) {
 
 	return strings.TrimSuffix(result.String(), "\n"), equal
 }
+
+// Severity returns how a failure of the resource change is treated
+func (c *UpdateComparer) Severity(r plan.ResourceChange) string {
+	return c.lookup(r).severity()
+}
+
+// RuleID returns the id of the rule for the resource change, or an empty string if it has none
+func (c *UpdateComparer) RuleID(r plan.ResourceChange) string {
+	return c.lookup(r).meta.ID
+}
+
+// Failures returns the arguments that fail the before or after rules for the resource change
+func (c *UpdateComparer) Failures(r plan.ResourceChange) []string {
+	ur := c.lookupUpdate(r)
+	failed := make(map[string]bool)
+	if ur.Before != nil {
+		for _, f := range ur.Before.failures(resource.ResourceValues{
+			Values:        r.GetBefore(),
+			ChangedValues: r.GetBeforeChangedOnly(),
+		}) {
+			failed[f] = true
+		}
+	}
+	if ur.After != nil {
+		for _, f := range ur.After.failures(resource.ResourceValues{
+			Values:        r.GetAfter(),
+			ChangedValues: r.GetAfterChangedOnly(),
+			Computed:      r.GetComputed(),
+		}) {
+			failed[f] = true
+		}
+	}
+
+	var result []string
+	for f := range failed {
+		result = append(result, f)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// lookup returns the rule for the resource change
+func (c *UpdateComparer) lookup(r plan.ResourceChange) resourceWithOpts {
+	return c.lookupUpdate(r).rule()
+}
+
+// lookupUpdate returns the before and after rules for the resource change, in the same order of priority as Compare and Diff
+func (c *UpdateComparer) lookupUpdate(r plan.ResourceChange) updateResource {
+	if ur, ok := lookupUpdateResource(c.NameTypeResources, r, constructNameTypeKey(r)); ok {
+		return ur
+	} else if ur, ok := lookupUpdateResource(c.NameResources, r, r.GetName()); ok {
+		return ur
+	} else if ur, ok := lookupUpdateResource(c.TypeResources, r, r.GetType()); ok {
+		return ur
+	}
+	return updateResource{}
+}
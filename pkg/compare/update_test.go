@@ -507,3 +507,66 @@ func TestUpdateDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateFailures(t *testing.T) {
+	cases := map[string]struct {
+		resource updateResource
+		expected []string
+	}{
+		"before and after failures": {
+			resource: updateResource{
+				Before: &resourceWithOpts{
+					resource: &resourcefakes.FakeResource{
+						FailuresReturns: []string{"name", "zone"},
+					},
+				},
+				After: &resourceWithOpts{
+					resource: &resourcefakes.FakeResource{
+						FailuresReturns: []string{"machine_type", "zone"},
+					},
+				},
+			},
+			expected: []string{"machine_type", "name", "zone"},
+		},
+		"after only": {
+			resource: updateResource{
+				After: &resourceWithOpts{
+					resource: &resourcefakes.FakeResource{
+						FailuresReturns: []string{"zone"},
+					},
+				},
+			},
+			expected: []string{"zone"},
+		},
+		"no failures": {
+			resource: updateResource{
+				Before: &resourceWithOpts{
+					resource: &resourcefakes.FakeResource{},
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			comparer := &UpdateComparer{
+				NameTypeResources: map[string]updateResource{
+					"type.name": tc.resource,
+				},
+			}
+			r := &planfakes.FakeResourceChange{
+				NameReturns: "name",
+				TypeReturns: "type",
+			}
+			got := comparer.Failures(r)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("Expected: %v but got %v", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("Expected: %v but got %v", tc.expected, got)
+				}
+			}
+		})
+	}
+}
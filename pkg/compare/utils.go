@@ -2,9 +2,11 @@ package compare
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/drlau/akashi/pkg/resource"
 	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
 )
 
 type ResourceChange interface {
@@ -16,20 +18,25 @@ type ResourceChange interface {
 	GetName() string
 	GetType() string
 	GetAddress() string
+	GetMode() string
 }
 
 type resourceWithOpts struct {
 	resource resource.Resource
 	opts     resource.CompareOptions
+
+	// meta describes the rule in the diff when it fails
+	meta ruleset.RuleMetadata
 }
 
 func newCreateDeleteResourceWithOpts(resourceConfig ruleset.CreateDeleteResourceChange, defaultOptions resource.CompareOptions) resourceWithOpts {
-	return newResourceWithOpts(resourceConfig.ResourceIdentifier, resourceConfig.ResourceRules, resourceConfig.CompareOptions, defaultOptions)
+	return newResourceWithOpts(resourceConfig.ResourceIdentifier, resourceConfig.ResourceRules, resourceConfig.CompareOptions, resourceConfig.RuleMetadata, defaultOptions)
 }
 
-func newResourceWithOpts(resourceIdentifier ruleset.ResourceIdentifier, resourceRules ruleset.ResourceRules, resourceOpts ruleset.CompareOptions, defaultOptions resource.CompareOptions) resourceWithOpts {
+func newResourceWithOpts(resourceIdentifier ruleset.ResourceIdentifier, resourceRules ruleset.ResourceRules, resourceOpts ruleset.CompareOptions, meta ruleset.RuleMetadata, defaultOptions resource.CompareOptions) resourceWithOpts {
 	return resourceWithOpts{
 		resource: resource.NewResourceFromConfig(resourceIdentifier, resourceRules),
+		meta:     meta,
 		opts: resource.CompareOptions{
 			EnforceAll:      boolFromBoolPointer(resourceOpts.EnforceAll, defaultOptions.EnforceAll),
 			IgnoreExtraArgs: boolFromBoolPointer(resourceOpts.IgnoreExtraArgs, defaultOptions.IgnoreExtraArgs),
@@ -45,8 +52,65 @@ func (r resourceWithOpts) compare(rv resource.ResourceValues) bool {
 	return r.resource.Compare(rv, r.opts)
 }
 
+// diff returns the differences from the rule, preceded by the metadata of the rule if there are any
 func (r resourceWithOpts) diff(rv resource.ResourceValues) string {
-	return r.resource.Diff(rv, r.opts)
+	diff := r.resource.Diff(rv, r.opts)
+	if diff == "" {
+		return ""
+	}
+	return describeRule(r.meta) + diff
+}
+
+func (r resourceWithOpts) failures(rv resource.ResourceValues) []string {
+	if r.resource == nil {
+		return nil
+	}
+	return r.resource.Failures(rv, r.opts)
+}
+
+// severity returns how a failure of the rule is treated, where a rule without a severity is an error
+func (r resourceWithOpts) severity() string {
+	if r.meta.Severity == "" {
+		return ruleset.SeverityError
+	}
+	return r.meta.Severity
+}
+
+// color returns the color to write a failure of the rule in
+func (r resourceWithOpts) color() func(string) string {
+	return severityColor(r.severity())
+}
+
+func severityColor(severity string) func(string) string {
+	switch severity {
+	case ruleset.SeverityWarning:
+		return utils.Yellow
+	case ruleset.SeverityInfo:
+		return utils.Cyan
+	default:
+		return utils.Red
+	}
+}
+
+// describeRule writes a line for each metadata field that is set, so a failure says which policy was broken
+func describeRule(meta ruleset.RuleMetadata) string {
+	var buf strings.Builder
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Rule", meta.ID},
+		{"Description", meta.Description},
+		{"Severity", meta.Severity},
+		{"Owner", meta.Owner},
+		{"Docs", meta.Docs},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			buf.WriteString(fmt.Sprintf("%s %s\n", utils.Bold(f.label+":"), f.value))
+		}
+	}
+	return buf.String()
 }
 
 func makeDefaultCompareOptions(config *ruleset.CompareOptions) resource.CompareOptions {
@@ -67,6 +131,42 @@ func constructNameTypeKey(r ResourceChange) string {
 	return fmt.Sprintf("%s.%s", r.GetType(), r.GetName())
 }
 
+// constructModeKey prefixes the key with the mode for rules that are restricted to a mode
+func constructModeKey(mode, key string) string {
+	if mode == "" {
+		return key
+	}
+	return fmt.Sprintf("%s:%s", mode, key)
+}
+
+// lookupResourceWithOpts returns the rule for the key, preferring a rule restricted to the resource change's mode
+func lookupResourceWithOpts(resources map[string]resourceWithOpts, r ResourceChange, key string) (resourceWithOpts, bool) {
+	if ro, ok := resources[constructModeKey(r.GetMode(), key)]; ok {
+		return ro, true
+	}
+	ro, ok := resources[key]
+	return ro, ok
+}
+
+// lookupRule returns the rule for the resource change, in the same order of priority as the comparers use
+func lookupRule(nameTypeResources, nameResources, typeResources map[string]resourceWithOpts, r ResourceChange) (resourceWithOpts, bool) {
+	if ro, ok := lookupResourceWithOpts(nameTypeResources, r, constructNameTypeKey(r)); ok {
+		return ro, true
+	} else if ro, ok := lookupResourceWithOpts(nameResources, r, r.GetName()); ok {
+		return ro, true
+	}
+	return lookupResourceWithOpts(typeResources, r, r.GetType())
+}
+
+// lookupUpdateResource returns the rule for the key, preferring a rule restricted to the resource change's mode
+func lookupUpdateResource(resources map[string]updateResource, r ResourceChange, key string) (updateResource, bool) {
+	if ur, ok := resources[constructModeKey(r.GetMode(), key)]; ok {
+		return ur, true
+	}
+	ur, ok := resources[key]
+	return ur, ok
+}
+
 func boolFromBoolPointer(b *bool, failover bool) bool {
 	if b != nil {
 		return *b
@@ -0,0 +1,124 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drlau/akashi/pkg/resource"
+	"github.com/drlau/akashi/pkg/ruleset"
+	"github.com/drlau/akashi/pkg/utils"
+)
+
+const variablesAddress = "variables"
+
+// VariablesComparer compares the root module input variables of a plan
+// Unlike the other comparers, it operates on the whole plan rather than a single resource change
+type VariablesComparer struct {
+	Rules []variableRule
+}
+
+type variableRule struct {
+	// When is nil if the rule always applies
+	When resource.Resource
+	Rule resourceWithOpts
+}
+
+// whenOptions requires every condition to be present and match, regardless of the values of other variables
+var whenOptions = resource.CompareOptions{
+	EnforceAll:      true,
+	IgnoreExtraArgs: true,
+}
+
+func NewVariablesComparer(ruleset ruleset.VariableRules) *VariablesComparer {
+	defaultOptions := makeDefaultCompareOptions(ruleset.Default)
+	rules := make([]variableRule, 0, len(ruleset.Rules))
+
+	for _, r := range ruleset.Rules {
+		rules = append(rules, newVariableRule(r, defaultOptions))
+	}
+
+	return &VariablesComparer{
+		Rules: rules,
+	}
+}
+
+func (c *VariablesComparer) Compare(variables map[string]interface{}) bool {
+	values := resource.ResourceValues{
+		Values: variables,
+	}
+
+	for _, r := range c.Rules {
+		if !r.applies(values) {
+			continue
+		}
+		if !r.Rule.compare(values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *VariablesComparer) Diff(variables map[string]interface{}) (string, bool) {
+	values := resource.ResourceValues{
+		Values: variables,
+	}
+
+	var result strings.Builder
+	for i, r := range c.Rules {
+		if !r.applies(values) {
+			continue
+		}
+		diff := r.Rule.diff(values)
+		if diff != "" {
+			color := r.Rule.color()
+			result.WriteString(fmt.Sprintf("%s %s %s\n%s\n", color("×"), color(variablesAddress), color(fmt.Sprintf("(rule %d)", i+1)), diff))
+		}
+	}
+
+	if result.Len() == 0 {
+		return fmt.Sprintf("%s %s", utils.Green("✓"), variablesAddress), true
+	}
+
+	return strings.TrimSuffix(result.String(), "\n"), false
+}
+
+// Severity returns the severity of the most severe failing rule, or an empty string if every rule passes
+func (c *VariablesComparer) Severity(variables map[string]interface{}) string {
+	values := resource.ResourceValues{
+		Values: variables,
+	}
+
+	result := ""
+	for _, r := range c.Rules {
+		if !r.applies(values) || r.Rule.compare(values) {
+			continue
+		}
+		if severity := r.Rule.severity(); result == "" || !ruleset.SeverityAtLeast(result, severity) {
+			result = severity
+		}
+	}
+
+	return result
+}
+
+func newVariableRule(ruleConfig ruleset.VariableRule, defaultOptions resource.CompareOptions) variableRule {
+	vr := variableRule{
+		Rule: newResourceWithOpts(ruleset.ResourceIdentifier{}, ruleConfig.ResourceRules, ruleConfig.CompareOptions, ruleConfig.RuleMetadata, defaultOptions),
+	}
+	if len(ruleConfig.When) > 0 {
+		vr.When = resource.NewResourceFromConfig(ruleset.ResourceIdentifier{}, ruleset.ResourceRules{
+			Enforced: ruleConfig.When,
+		})
+	}
+
+	return vr
+}
+
+func (r variableRule) applies(values resource.ResourceValues) bool {
+	if r.When == nil {
+		return true
+	}
+
+	return r.When.Compare(values, whenOptions)
+}
@@ -0,0 +1,330 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestVariablesCompare(t *testing.T) {
+	trueValue := true
+	cases := map[string]struct {
+		ruleset   ruleset.VariableRules
+		variables map[string]interface{}
+		expected  bool
+	}{
+		"matching value": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"environment": {
+									MatchAny: []interface{}{"dev", "prod"},
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"environment": "prod",
+			},
+			expected: true,
+		},
+		"non-matching value": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"region": {
+									Match: "^us-",
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"region": "europe-west1",
+			},
+			expected: false,
+		},
+		"extra variables with default options": {
+			ruleset: ruleset.VariableRules{
+				Default: &ruleset.CompareOptions{
+					IgnoreExtraArgs: &trueValue,
+				},
+				Rules: []ruleset.VariableRule{
+					{
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"region": {
+									Match: "^us-",
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"region": "us-central1",
+				"extra":  "value",
+			},
+			expected: true,
+		},
+		"when condition met": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						When: map[string]ruleset.EnforceChange{
+							"environment": {
+								Value: "prod",
+							},
+						},
+						CompareOptions: ruleset.CompareOptions{
+							IgnoreExtraArgs: &trueValue,
+						},
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"enable_deletion_protection": {
+									Value: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"environment":                "prod",
+				"enable_deletion_protection": false,
+			},
+			expected: false,
+		},
+		"when condition not met": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						When: map[string]ruleset.EnforceChange{
+							"environment": {
+								Value: "prod",
+							},
+						},
+						CompareOptions: ruleset.CompareOptions{
+							IgnoreExtraArgs: &trueValue,
+						},
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"enable_deletion_protection": {
+									Value: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"environment":                "dev",
+				"enable_deletion_protection": false,
+			},
+			expected: true,
+		},
+		"when condition on missing variable": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						When: map[string]ruleset.EnforceChange{
+							"environment": {
+								Value: "prod",
+							},
+						},
+						CompareOptions: ruleset.CompareOptions{
+							AutoFail: &trueValue,
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{},
+			expected:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := NewVariablesComparer(tc.ruleset).Compare(tc.variables); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestVariablesDiff(t *testing.T) {
+	cases := map[string]struct {
+		ruleset        ruleset.VariableRules
+		variables      map[string]interface{}
+		expected       bool
+		expectedOutput []string
+	}{
+		"matching value": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"environment": {
+									Value: "prod",
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"environment": "prod",
+			},
+			expected:       true,
+			expectedOutput: []string{"✓", "variables"},
+		},
+		"failing rule": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"environment": {
+									Value: "prod",
+								},
+							},
+						},
+					},
+					{
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"environment": {
+									Value: "dev",
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"environment": "prod",
+			},
+			expected:       false,
+			expectedOutput: []string{"×", "variables", "(rule 2)", "environment"},
+		},
+		"failing rule with metadata": {
+			ruleset: ruleset.VariableRules{
+				Rules: []ruleset.VariableRule{
+					{
+						RuleMetadata: ruleset.RuleMetadata{
+							ID:       "prod-only",
+							Severity: ruleset.SeverityWarning,
+							Owner:    "platform-team",
+						},
+						ResourceRules: ruleset.ResourceRules{
+							Enforced: map[string]ruleset.EnforceChange{
+								"environment": {
+									Value: "prod",
+								},
+							},
+						},
+					},
+				},
+			},
+			variables: map[string]interface{}{
+				"environment": "dev",
+			},
+			expected:       false,
+			expectedOutput: []string{"×", "variables", "Rule:", "prod-only", "Severity:", "warning", "Owner:", "platform-team", "environment"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			output, got := NewVariablesComparer(tc.ruleset).Diff(tc.variables)
+			if got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+
+			for _, s := range tc.expectedOutput {
+				if !strings.Contains(output, s) {
+					t.Errorf("Result string did not contain %v", s)
+				}
+			}
+		})
+	}
+}
+
+func TestVariablesSeverity(t *testing.T) {
+	trueValue := true
+	comparer := NewVariablesComparer(ruleset.VariableRules{
+		Rules: []ruleset.VariableRule{
+			{
+				RuleMetadata: ruleset.RuleMetadata{
+					Severity: ruleset.SeverityInfo,
+				},
+				ResourceRules: ruleset.ResourceRules{
+					Enforced: map[string]ruleset.EnforceChange{
+						"environment": {
+							Value: "prod",
+						},
+					},
+				},
+			},
+			{
+				RuleMetadata: ruleset.RuleMetadata{
+					Severity: ruleset.SeverityWarning,
+				},
+				ResourceRules: ruleset.ResourceRules{
+					Enforced: map[string]ruleset.EnforceChange{
+						"region": {
+							Match: "^us-",
+						},
+					},
+				},
+			},
+		},
+		Default: &ruleset.CompareOptions{
+			IgnoreExtraArgs: &trueValue,
+		},
+	})
+
+	cases := map[string]struct {
+		variables map[string]interface{}
+		expected  string
+	}{
+		"every rule passes": {
+			variables: map[string]interface{}{
+				"environment": "prod",
+				"region":      "us-east1",
+			},
+			expected: "",
+		},
+		"one rule fails": {
+			variables: map[string]interface{}{
+				"environment": "dev",
+				"region":      "us-east1",
+			},
+			expected: ruleset.SeverityInfo,
+		},
+		"most severe failing rule": {
+			variables: map[string]interface{}{
+				"environment": "dev",
+				"region":      "eu-west1",
+			},
+			expected: ruleset.SeverityWarning,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := comparer.Severity(tc.variables); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
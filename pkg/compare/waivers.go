@@ -0,0 +1,99 @@
+package compare
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+// Waivers grant time-boxed exceptions to resource rules, and record which waivers are used
+// A nil Waivers waives nothing
+type Waivers struct {
+	waivers  []ruleset.Waiver
+	patterns []*regexp.Regexp
+	used     []bool
+
+	// now is the clock expiry dates are compared with
+	now func() time.Time
+}
+
+func NewWaivers(waivers []ruleset.Waiver, now func() time.Time) *Waivers {
+	patterns := make([]*regexp.Regexp, 0, len(waivers))
+	for _, w := range waivers {
+		patterns = append(patterns, addressPattern(w.Address))
+	}
+
+	return &Waivers{
+		waivers:  waivers,
+		patterns: patterns,
+		used:     make([]bool, len(waivers)),
+		now:      now,
+	}
+}
+
+// Lookup returns the waiver for a failure of the rule for the address, and whether it has expired
+// A waiver that is still in effect is preferred over an expired one
+func (w *Waivers) Lookup(address, rule string) (ruleset.Waiver, bool, bool) {
+	if w == nil || rule == "" {
+		return ruleset.Waiver{}, false, false
+	}
+
+	var (
+		result ruleset.Waiver
+		found  bool
+	)
+	now := w.now()
+	for i, waiver := range w.waivers {
+		if waiver.Rule != rule || !w.patterns[i].MatchString(address) {
+			continue
+		}
+		w.used[i] = true
+		if !found || result.Expired(now) {
+			result = waiver
+			found = true
+		}
+	}
+
+	return result, found && result.Expired(now), found
+}
+
+// Waives returns true if a waiver in effect applies to a failure of the rule for the address
+func (w *Waivers) Waives(address, rule string) bool {
+	_, expired, ok := w.Lookup(address, rule)
+	return ok && !expired
+}
+
+// Used returns the waivers that have matched a failure
+func (w *Waivers) Used() []ruleset.Waiver {
+	return w.filter(true)
+}
+
+// Unused returns the waivers that have not matched any failure
+func (w *Waivers) Unused() []ruleset.Waiver {
+	return w.filter(false)
+}
+
+func (w *Waivers) filter(used bool) []ruleset.Waiver {
+	if w == nil {
+		return nil
+	}
+
+	var result []ruleset.Waiver
+	for i, waiver := range w.waivers {
+		if w.used[i] == used {
+			result = append(result, waiver)
+		}
+	}
+	return result
+}
+
+// addressPattern converts the address pattern of a waiver into a regular expression, where only "*" is special
+func addressPattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
@@ -0,0 +1,114 @@
+package compare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/drlau/akashi/pkg/ruleset"
+)
+
+func TestWaiversLookup(t *testing.T) {
+	now := func() time.Time {
+		return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	}
+	active := ruleset.Waiver{
+		Address:    "module.legacy.google_compute_instance.*",
+		Rule:       "approved-zones",
+		Reason:     "migrating",
+		ApprovedBy: "platform-team",
+		Expires:    "2026-11-01",
+	}
+	expired := ruleset.Waiver{
+		Address:    "google_compute_instance.web[\"a\"]",
+		Rule:       "approved-zones",
+		Reason:     "temporary",
+		ApprovedBy: "platform-team",
+		Expires:    "2026-10-18",
+	}
+	renewed := expired
+	renewed.Expires = "2026-12-01"
+
+	cases := map[string]struct {
+		waivers         []ruleset.Waiver
+		address         string
+		rule            string
+		expectedWaiver  ruleset.Waiver
+		expectedExpired bool
+		expectedOk      bool
+	}{
+		"matching pattern": {
+			waivers:        []ruleset.Waiver{active},
+			address:        "module.legacy.google_compute_instance.web",
+			rule:           "approved-zones",
+			expectedWaiver: active,
+			expectedOk:     true,
+		},
+		"pattern does not match": {
+			waivers: []ruleset.Waiver{active},
+			address: "module.other.google_compute_instance.web",
+			rule:    "approved-zones",
+		},
+		"different rule": {
+			waivers: []ruleset.Waiver{active},
+			address: "module.legacy.google_compute_instance.web",
+			rule:    "approved-types",
+		},
+		"rule without id": {
+			waivers: []ruleset.Waiver{active},
+			address: "module.legacy.google_compute_instance.web",
+		},
+		"brackets are matched literally": {
+			waivers:         []ruleset.Waiver{expired},
+			address:         "google_compute_instance.web[\"a\"]",
+			rule:            "approved-zones",
+			expectedWaiver:  expired,
+			expectedExpired: true,
+			expectedOk:      true,
+		},
+		"prefers a waiver that has not expired": {
+			waivers:        []ruleset.Waiver{expired, renewed},
+			address:        "google_compute_instance.web[\"a\"]",
+			rule:           "approved-zones",
+			expectedWaiver: renewed,
+			expectedOk:     true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			waiver, expired, ok := NewWaivers(tc.waivers, now).Lookup(tc.address, tc.rule)
+			if ok != tc.expectedOk {
+				t.Errorf("Expected: %v but got %v", tc.expectedOk, ok)
+			}
+			if expired != tc.expectedExpired {
+				t.Errorf("Expected expired: %v but got %v", tc.expectedExpired, expired)
+			}
+			if diff := cmp.Diff(tc.expectedWaiver, waiver); diff != "" {
+				t.Errorf("Result mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWaiversUnused(t *testing.T) {
+	waivers := []ruleset.Waiver{
+		{Address: "google_compute_instance.*", Rule: "approved-zones", Expires: "2026-11-01"},
+		{Address: "google_compute_disk.*", Rule: "approved-zones", Expires: "2026-11-01"},
+	}
+	w := NewWaivers(waivers, time.Now)
+	w.Lookup("google_compute_instance.web", "approved-zones")
+
+	if diff := cmp.Diff(waivers[:1], w.Used()); diff != "" {
+		t.Errorf("Used mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(waivers[1:], w.Unused()); diff != "" {
+		t.Errorf("Unused mismatch (-expected +got):\n%s", diff)
+	}
+
+	var none *Waivers
+	if none.Waives("google_compute_instance.web", "approved-zones") {
+		t.Errorf("Expected nil waivers to waive nothing")
+	}
+}
@@ -0,0 +1,262 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	cfnChangeTypeResource = "Resource"
+
+	cfnActionAdd    = "Add"
+	cfnActionModify = "Modify"
+	cfnActionRemove = "Remove"
+	cfnActionImport = "Import"
+
+	cfnStatusFailed = "FAILED"
+
+	// cfnReplacementTrue and cfnReplacementConditional are the values of Replacement for resources that may be replaced
+	cfnReplacementTrue        = "True"
+	cfnReplacementConditional = "Conditional"
+
+	// cfnNoChangesReason is the start of the status reason of a change set that failed because there were no changes
+	cfnNoChangesReason = "The submitted information didn't contain changes"
+
+	cfnAttributeProperties = "Properties"
+	cfnEvaluationDynamic   = "Dynamic"
+)
+
+// errCFNValuesOmitted is the reason a change set without property values leaves them out
+var errCFNValuesOmitted = errors.New("the change set was described without --include-property-values")
+
+// cfnChangeSet is the output of "aws cloudformation describe-change-set"
+type cfnChangeSet struct {
+	ChangeSetName string       `json:"ChangeSetName"`
+	ChangeSetId   string       `json:"ChangeSetId"`
+	Status        string       `json:"Status"`
+	StatusReason  string       `json:"StatusReason"`
+	NextToken     string       `json:"NextToken"`
+	Changes       []*cfnChange `json:"Changes"`
+}
+
+type cfnChange struct {
+	Type           string             `json:"Type"`
+	ResourceChange *cfnResourceChange `json:"ResourceChange"`
+}
+
+type cfnResourceChange struct {
+	Action            string       `json:"Action"`
+	LogicalResourceId string       `json:"LogicalResourceId"`
+	ResourceType      string       `json:"ResourceType"`
+	Replacement       string       `json:"Replacement"`
+	Details           []*cfnDetail `json:"Details"`
+
+	// BeforeContext and AfterContext are JSON documents of the resource
+	// They are only included when the change set is described with --include-property-values
+	BeforeContext string `json:"BeforeContext"`
+	AfterContext  string `json:"AfterContext"`
+}
+
+type cfnDetail struct {
+	Target     cfnTarget `json:"Target"`
+	Evaluation string    `json:"Evaluation"`
+}
+
+type cfnTarget struct {
+	Attribute string `json:"Attribute"`
+	Name      string `json:"Name"`
+}
+
+// cfnResourceContext is the document in BeforeContext and AfterContext
+type cfnResourceContext struct {
+	Properties map[string]interface{} `json:"Properties"`
+}
+
+// cfnChangeSetChange is a resource change in a CloudFormation change set, identified by its logical ID
+type cfnChangeSetChange struct {
+	Change *cfnResourceChange
+
+	before map[string]interface{}
+	after  map[string]interface{}
+}
+
+// NewPlanFromChangeSet reads the output of "aws cloudformation describe-change-set"
+// Paginated output is rejected, as the changes on the other pages would be missed
+func NewPlanFromChangeSet(in io.Reader) (*Plan, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var changeSet cfnChangeSet
+	if err := json.Unmarshal(data, &changeSet); err != nil {
+		return nil, err
+	}
+
+	if changeSet.NextToken != "" {
+		return nil, fmt.Errorf("change set output has more pages, describe it without --max-items to read every change")
+	}
+	if changeSet.Status == cfnStatusFailed && !strings.HasPrefix(changeSet.StatusReason, cfnNoChangesReason) {
+		return nil, fmt.Errorf("change set failed: %s", changeSet.StatusReason)
+	}
+
+	result := &Plan{
+		Tool: ToolCloudFormation,
+	}
+	for i, c := range changeSet.Changes {
+		if c == nil || c.Type != cfnChangeTypeResource {
+			continue
+		}
+		if c.ResourceChange == nil || c.ResourceChange.LogicalResourceId == "" {
+			return nil, fmt.Errorf("change %d is missing the logical resource id", i)
+		}
+		// imported resources are not changed, in the same way terraform imports are skipped
+		if c.ResourceChange.Action == cfnActionImport {
+			continue
+		}
+
+		rc, err := newCFNChangeSetChange(c.ResourceChange)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.ResourceChange.LogicalResourceId, err)
+		}
+		if c.ResourceChange.BeforeContext == "" && c.ResourceChange.AfterContext == "" {
+			result.ValuesOmitted = errCFNValuesOmitted
+		}
+		result.ResourceChanges = append(result.ResourceChanges, rc)
+	}
+
+	return result, nil
+}
+
+func newCFNChangeSetChange(change *cfnResourceChange) (ResourceChange, error) {
+	before, err := cfnProperties(change.BeforeContext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BeforeContext: %v", err)
+	}
+	after, err := cfnProperties(change.AfterContext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AfterContext: %v", err)
+	}
+
+	return &cfnChangeSetChange{
+		Change: change,
+		before: before,
+		after:  after,
+	}, nil
+}
+
+// cfnProperties returns the properties of a resource context, or an empty map if there is no context
+func cfnProperties(context string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if context == "" {
+		return result, nil
+	}
+
+	var parsed cfnResourceContext
+	if err := json.Unmarshal([]byte(context), &parsed); err != nil {
+		return nil, err
+	}
+	for k, v := range parsed.Properties {
+		result[k] = v
+	}
+	return result, nil
+}
+
+func (c *cfnChangeSetChange) IsCreate() bool {
+	return c.Change.Action == cfnActionAdd || c.isReplace()
+}
+
+func (c *cfnChangeSetChange) IsDelete() bool {
+	return c.Change.Action == cfnActionRemove || c.isReplace()
+}
+
+func (c *cfnChangeSetChange) IsNoOp() bool {
+	return false
+}
+
+func (c *cfnChangeSetChange) IsUpdate() bool {
+	return c.Change.Action == cfnActionModify && !c.isReplace()
+}
+
+func (c *cfnChangeSetChange) IsRead() bool {
+	return false
+}
+
+func (c *cfnChangeSetChange) GetBefore() map[string]interface{} {
+	return c.before
+}
+
+func (c *cfnChangeSetChange) GetAfter() map[string]interface{} {
+	return c.after
+}
+
+func (c *cfnChangeSetChange) GetBeforeChangedOnly() map[string]interface{} {
+	return c.changedOnly(c.before)
+}
+
+func (c *cfnChangeSetChange) GetAfterChangedOnly() map[string]interface{} {
+	return c.changedOnly(c.after)
+}
+
+// GetComputed returns the changed properties that CloudFormation can only evaluate when the change set is executed
+func (c *cfnChangeSetChange) GetComputed() map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, d := range c.Change.Details {
+		if d != nil && d.Target.Attribute == cfnAttributeProperties && d.Evaluation == cfnEvaluationDynamic {
+			result[cfnPropertyName(d.Target.Name)] = true
+		}
+	}
+	return result
+}
+
+func (c *cfnChangeSetChange) GetName() string {
+	return c.Change.LogicalResourceId
+}
+
+func (c *cfnChangeSetChange) GetType() string {
+	return c.Change.ResourceType
+}
+
+func (c *cfnChangeSetChange) GetAddress() string {
+	return c.Change.LogicalResourceId
+}
+
+func (c *cfnChangeSetChange) GetMode() string {
+	return ManagedMode
+}
+
+// isReplace returns true if the resource is replaced, which is both a delete and a create
+func (c *cfnChangeSetChange) isReplace() bool {
+	if c.Change.Action != cfnActionModify {
+		return false
+	}
+	return c.Change.Replacement == cfnReplacementTrue || c.Change.Replacement == cfnReplacementConditional
+}
+
+// changedOnly returns the values of the properties targeted by the details of a modification, or all values otherwise
+func (c *cfnChangeSetChange) changedOnly(values map[string]interface{}) map[string]interface{} {
+	if c.Change.Action != cfnActionModify {
+		return values
+	}
+
+	result := map[string]interface{}{}
+	for _, d := range c.Change.Details {
+		if d == nil || d.Target.Attribute != cfnAttributeProperties {
+			continue
+		}
+		name := cfnPropertyName(d.Target.Name)
+		if v, ok := values[name]; ok {
+			result[name] = v
+		}
+	}
+	return result
+}
+
+// cfnPropertyName returns the top level property of a target, as the targets of nested properties are written as "Parent.Child"
+func cfnPropertyName(target string) string {
+	return strings.SplitN(target, ".", 2)[0]
+}
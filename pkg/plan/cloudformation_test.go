@@ -0,0 +1,195 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPlanFromChangeSet(t *testing.T) {
+	f, err := os.Open("testdata/changeset.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := NewPlanFromChangeSet(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []goldenResourceChange
+	for _, rc := range p.ResourceChanges {
+		got = append(got, newGoldenResourceChange(rc))
+	}
+
+	expected := []goldenResourceChange{
+		{
+			Address:  "LogsBucket",
+			Type:     "AWS::S3::Bucket",
+			Name:     "LogsBucket",
+			Mode:     ManagedMode,
+			Action:   "create",
+			Before:   map[string]interface{}{},
+			After:    map[string]interface{}{"AccessControl": "Private", "BucketName": "example-logs"},
+			Computed: map[string]interface{}{},
+		},
+		{
+			Address:  "WebInstance",
+			Type:     "AWS::EC2::Instance",
+			Name:     "WebInstance",
+			Mode:     ManagedMode,
+			Action:   "replace",
+			Before:   map[string]interface{}{"ImageId": "ami-0123456789", "InstanceType": "t3.micro", "KeyName": "web"},
+			After:    map[string]interface{}{"ImageId": "ami-9876543210", "InstanceType": "t3.small", "KeyName": "web"},
+			Computed: map[string]interface{}{"ImageId": true},
+		},
+		{
+			Address:  "OldQueue",
+			Type:     "AWS::SQS::Queue",
+			Name:     "OldQueue",
+			Mode:     ManagedMode,
+			Action:   "delete",
+			Before:   map[string]interface{}{"QueueName": "old"},
+			After:    map[string]interface{}{},
+			Computed: map[string]interface{}{},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Resource changes mismatch (-expected +got):\n%s", diff)
+	}
+
+	expectedChanged := map[string]interface{}{"ImageId": "ami-9876543210", "InstanceType": "t3.small"}
+	if diff := cmp.Diff(expectedChanged, p.ResourceChanges[1].GetAfterChangedOnly()); diff != "" {
+		t.Errorf("Changed values mismatch (-expected +got):\n%s", diff)
+	}
+	if p.Tool != ToolCloudFormation {
+		t.Errorf("Expected tool %q but got %q", ToolCloudFormation, p.Tool)
+	}
+	if p.ValuesOmitted != nil {
+		t.Errorf("Expected values to be included but got: %v", p.ValuesOmitted)
+	}
+}
+
+func TestNewPlanFromChangeSetActions(t *testing.T) {
+	cases := map[string]struct {
+		change   string
+		expected string
+	}{
+		"modify": {
+			change:   `{"Action":"Modify","LogicalResourceId":"Web","Replacement":"False"}`,
+			expected: "update",
+		},
+		"replace": {
+			change:   `{"Action":"Modify","LogicalResourceId":"Web","Replacement":"True"}`,
+			expected: "replace",
+		},
+		"conditional replace": {
+			change:   `{"Action":"Modify","LogicalResourceId":"Web","Replacement":"Conditional"}`,
+			expected: "replace",
+		},
+		"add": {
+			change:   `{"Action":"Add","LogicalResourceId":"Web"}`,
+			expected: "create",
+		},
+		"remove": {
+			change:   `{"Action":"Remove","LogicalResourceId":"Web"}`,
+			expected: "delete",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":` + tc.change + `}]}`
+			p, err := NewPlanFromChangeSet(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := newGoldenResourceChange(p.ResourceChanges[0]).Action; got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromChangeSetValuesOmitted(t *testing.T) {
+	cases := map[string]struct {
+		input    string
+		expected bool
+	}{
+		"without property values": {
+			input:    `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Add","LogicalResourceId":"Bucket"}}]}`,
+			expected: true,
+		},
+		"with property values": {
+			input:    `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Add","LogicalResourceId":"Bucket","AfterContext":"{}"}}]}`,
+			expected: false,
+		},
+		"only imports": {
+			input:    `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Import","LogicalResourceId":"Table"}}]}`,
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewPlanFromChangeSet(strings.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := p.ValuesOmitted != nil; got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromChangeSetErrors(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		err   string
+	}{
+		"paginated output": {
+			input: `{"ChangeSetId":"arn","Changes":[],"NextToken":"abc"}`,
+			err:   "more pages",
+		},
+		"failed change set": {
+			input: `{"ChangeSetId":"arn","Changes":[],"Status":"FAILED","StatusReason":"Template format error"}`,
+			err:   "change set failed: Template format error",
+		},
+		"missing logical resource id": {
+			input: `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Add"}}]}`,
+			err:   "change 0 is missing the logical resource id",
+		},
+		"invalid context": {
+			input: `{"ChangeSetId":"arn","Changes":[{"Type":"Resource","ResourceChange":{"Action":"Add","LogicalResourceId":"Bucket","AfterContext":"{"}}]}`,
+			err:   "Bucket: invalid AfterContext",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPlanFromChangeSet(strings.NewReader(tc.input))
+			if err == nil {
+				t.Fatalf("Expected an error but got none")
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected error to contain %q but got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromChangeSetNoChanges(t *testing.T) {
+	input := `{"ChangeSetId":"arn","Changes":[],"Status":"FAILED","StatusReason":"The submitted information didn't contain changes. Submit different information to create a change set."}`
+
+	p, err := NewPlanFromChangeSet(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(p.ResourceChanges) != 0 {
+		t.Errorf("Expected no resource changes but got %d", len(p.ResourceChanges))
+	}
+}
@@ -0,0 +1,153 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// Format is a kind of input that a Plan can be read from
+type Format string
+
+const (
+	// FormatText is the human readable output of "terraform plan"
+	FormatText Format = "terraform plan output"
+	// FormatJSONPlan is the output of "terraform show -json" of a saved plan
+	FormatJSONPlan Format = "JSON plan"
+	// FormatJSONState is the output of "terraform show -json" of a state
+	FormatJSONState Format = "JSON state"
+	// FormatJSONStream is the machine readable output of "terraform plan -json"
+	FormatJSONStream Format = "JSON stream"
+	// FormatPulumiPreview is the output of "pulumi preview --json"
+	FormatPulumiPreview Format = "Pulumi preview"
+	// FormatChangeSet is the output of "aws cloudformation describe-change-set"
+	FormatChangeSet Format = "CloudFormation change set"
+)
+
+// jsonKeyFormats are top level keys that identify the format of a JSON document, where the first of them in the document is used
+var jsonKeyFormats = map[string]Format{
+	// every message of the machine readable output of "terraform plan -json" has a level
+	"@level":           FormatJSONStream,
+	"planned_values":   FormatJSONPlan,
+	"resource_changes": FormatJSONPlan,
+	"values":           FormatJSONState,
+	"steps":            FormatPulumiPreview,
+	"ChangeSetId":      FormatChangeSet,
+}
+
+// DetectFormat reads the start of the input and reports which format it is in
+// The returned reader yields the whole input, and should be used in place of in
+func DetectFormat(in io.Reader) (Format, io.Reader, error) {
+	r := bufio.NewReader(in)
+
+	// keep the leading whitespace so the returned reader yields the whole input
+	var read bytes.Buffer
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return FormatText, &read, nil
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		if err := r.UnreadByte(); err != nil {
+			return "", nil, err
+		}
+		if !unicode.IsSpace(rune(b)) {
+			if b != '{' {
+				return FormatText, io.MultiReader(&read, r), nil
+			}
+			break
+		}
+		read.WriteByte(b)
+		if _, err := r.ReadByte(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	// the decoder reads ahead, so everything it reads is kept to be replayed
+	dec := json.NewDecoder(io.TeeReader(r, &read))
+	format, err := detectJSONFormat(dec)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return format, io.MultiReader(&read, r), nil
+}
+
+// detectJSONFormat reads the top level keys of a JSON document until a key identifies the format
+func detectJSONFormat(dec *json.Decoder) (Format, error) {
+	if _, err := dec.Token(); err != nil {
+		return "", fmt.Errorf("input looks like JSON but could not be parsed: %v", err)
+	}
+
+	hasFormatVersion := false
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("input looks like JSON but could not be parsed: %v", err)
+		}
+		key, _ := token.(string)
+		if format, ok := jsonKeyFormats[key]; ok {
+			return format, nil
+		}
+		if key == "format_version" {
+			hasFormatVersion = true
+		}
+		if err := skipJSONValue(dec); err != nil {
+			return "", fmt.Errorf("input looks like JSON but could not be parsed: %v", err)
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return "", fmt.Errorf("input looks like JSON but could not be parsed: %v", err)
+	}
+
+	// a state with no resources only has a format_version
+	if hasFormatVersion {
+		return FormatJSONState, nil
+	}
+	return "", fmt.Errorf("input is JSON but is not a terraform plan, state or log, a pulumi preview or a CloudFormation change set")
+}
+
+// skipJSONValue reads the next value from the decoder without holding it in memory
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// NewPlanFromFormat parses the input as the given format
+func NewPlanFromFormat(format Format, in io.Reader) (*Plan, error) {
+	switch format {
+	case FormatJSONPlan:
+		return NewPlanFromJSON(in)
+	case FormatJSONState:
+		return NewPlanFromStateJSON(in)
+	case FormatJSONStream:
+		return NewPlanFromJSONStream(in)
+	case FormatPulumiPreview:
+		return NewPlanFromPulumiPreview(in)
+	case FormatChangeSet:
+		return NewPlanFromChangeSet(in)
+	case FormatText:
+		return NewPlanFromPlanOutput(in)
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+}
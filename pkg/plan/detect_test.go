@@ -0,0 +1,126 @@
+package plan
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]struct {
+		file     string
+		input    string
+		expected Format
+		err      bool
+	}{
+		"text plan": {
+			file:     "testdata/plan-output/terraform-1.5.txt",
+			expected: FormatText,
+		},
+		"empty input": {
+			input:    "",
+			expected: FormatText,
+		},
+		"JSON plan": {
+			file:     "testdata/plan.json",
+			expected: FormatJSONPlan,
+		},
+		"JSON state": {
+			file:     "testdata/state.json",
+			expected: FormatJSONState,
+		},
+		"empty JSON state": {
+			input:    `{"format_version":"0.1"}`,
+			expected: FormatJSONState,
+		},
+		"JSON stream": {
+			file:     "testdata/plan-stream.jsonl",
+			expected: FormatJSONStream,
+		},
+		"Pulumi preview": {
+			file:     "testdata/pulumi-preview.json",
+			expected: FormatPulumiPreview,
+		},
+		"CloudFormation change set": {
+			file:     "testdata/changeset.json",
+			expected: FormatChangeSet,
+		},
+		"leading whitespace": {
+			input:    "\n  {\"format_version\":\"0.1\",\"resource_changes\":[]}",
+			expected: FormatJSONPlan,
+		},
+		"only reads up to the identifying key": {
+			input:    `{"format_version":"1.2","terraform_version":"1.7.0","resource_changes":[{"address":`,
+			expected: FormatJSONPlan,
+		},
+		"invalid JSON": {
+			input: `{"format_version":`,
+			err:   true,
+		},
+		"unrelated JSON": {
+			input: `{"foo":"bar"}`,
+			err:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := tc.input
+			if tc.file != "" {
+				b, err := ioutil.ReadFile(tc.file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				input = string(b)
+			}
+
+			format, out, err := DetectFormat(strings.NewReader(input))
+			if tc.err {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if format != tc.expected {
+				t.Errorf("Expected %q but got %q", tc.expected, format)
+			}
+
+			// the returned reader must still yield the whole input
+			b, err := ioutil.ReadAll(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != input {
+				t.Errorf("Expected the returned reader to yield the whole input")
+			}
+		})
+	}
+}
+
+func TestNewPlanFromFormat(t *testing.T) {
+	f, err := os.Open("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	format, data, err := DetectFormat(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	p, err := NewPlanFromFormat(format, data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(p.ResourceChanges) != 3 {
+		t.Errorf("Expected 3 resource changes but got %d", len(p.ResourceChanges))
+	}
+
+	if _, err := NewPlanFromFormat(Format("unknown"), strings.NewReader("")); err == nil {
+		t.Errorf("Expected an error for an unknown format but got none")
+	}
+}
@@ -8,6 +8,8 @@ type FakeResourceChange struct {
 	DeleteReturns   bool
 	NoOpReturns     bool
 	UpdateReturns   bool
+	ReadReturns     bool
+	ModeReturns     string
 	BeforeReturns   map[string]interface{}
 	AfterReturns    map[string]interface{}
 	ComputedReturns map[string]interface{}
@@ -41,6 +43,14 @@ func (r *FakeResourceChange) IsUpdate() bool {
 	return r.UpdateReturns
 }
 
+func (r *FakeResourceChange) IsRead() bool {
+	return r.ReadReturns
+}
+
+func (r *FakeResourceChange) GetMode() string {
+	return r.ModeReturns
+}
+
 func (r *FakeResourceChange) GetBefore() map[string]interface{} {
 	return r.BeforeReturns
 }
@@ -0,0 +1,306 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/drlau/tfplanparse"
+)
+
+const maxLineSize = 1024 * 1024
+
+var (
+	ansiPattern     = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	deposedPattern  = regexp.MustCompile(` \(deposed object [^)]*\)`)
+	knownAfterApply = regexp.MustCompile(`^([+~-]) ([^ ]+) \(known after apply\)$`)
+	summaryPattern  = regexp.MustCompile(`^Plan: (?:\d+ to import, )?(\d+) to add, (\d+) to change, (\d+) to destroy`)
+
+	// replacedSuffixes are resource comment suffixes added after terraform 0.12 that mean the resource is replaced
+	replacedSuffixes = []string{
+		" will be replaced, as requested",
+		" will be replaced due to changes in replace_triggered_by",
+	}
+
+	// changeSuffixes are the resource comment suffixes tfplanparse understands
+	changeSuffixes = []string{
+		tfplanparse.RESOURCE_CREATED,
+		tfplanparse.RESOURCE_READ,
+		tfplanparse.RESOURCE_UPDATED_IN_PLACE,
+		tfplanparse.RESOURCE_TAINTED,
+		tfplanparse.RESOURCE_REPLACED,
+		tfplanparse.RESOURCE_DESTROYED,
+	}
+
+	// unchangedPatterns match resource comments for resources that are listed without a planned change
+	unchangedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(` has moved to `),
+		regexp.MustCompile(` will be imported$`),
+		regexp.MustCompile(` will no longer be managed by (Terraform|OpenTofu)$`),
+	}
+
+	// openTofuChangesStart replaces CHANGES_START_STRING in the output of OpenTofu
+	openTofuChangesStart = "OpenTofu will perform the following actions:"
+
+	// toolPrefixes are the beginnings of lines that only the given tool prints before the changes
+	toolPrefixes = []struct {
+		prefix string
+		tool   string
+	}{
+		{"Terraform ", ToolTerraform},
+		{"Refreshing Terraform state", ToolTerraform},
+		{"OpenTofu ", ToolOpenTofu},
+	}
+
+	// heredocRemoved and heredocAdded mark the lines of an updated heredoc that are only in the value before or after the change
+	// tfplanparse adds every line of an updated heredoc to both values, so splitHeredocLines separates them after parsing
+	heredocRemoved = "\x00-"
+	heredocAdded   = "\x00+"
+
+	// noChangesStrings are printed instead of the changes when there is nothing to apply
+	noChangesStrings = []string{
+		"No changes.",
+		"You can apply this plan to save these new output values",
+	}
+)
+
+// planOutputInfo is the plan level information found while normalizing the output
+type planOutputInfo struct {
+	// Summary is the summary reported by the output, or nil if the output has no summary
+	Summary *Summary
+
+	// Tool is the tool that printed the output, or empty if it could not be determined
+	Tool string
+}
+
+// normalizePlanOutput rewrites the output of terraform 0.13 and later, and OpenTofu, into the terraform 0.12 format tfplanparse expects
+func normalizePlanOutput(in io.Reader) (io.Reader, planOutputInfo, error) {
+	var (
+		out     bytes.Buffer
+		info    planOutputInfo
+		started bool
+		heredoc bool
+		// heredocMarker is the column of the "-" and "+" markers of the lines of an updated heredoc, or -1 for other heredocs
+		heredocMarker int
+		skipping      bool
+		// skipIndent is the indentation of the skipped block, or nil if it has not been seen yet
+		skipIndent *string
+	)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for scanner.Scan() {
+		line := ansiPattern.ReplaceAllString(scanner.Text(), "")
+		text := strings.TrimSpace(line)
+
+		switch {
+		case heredoc:
+			if tfplanparse.IsHeredocAttributeTerminator(text) {
+				heredoc = false
+			} else if heredocMarker >= 0 {
+				line = markHeredocLine(line, heredocMarker)
+			}
+		case skipping:
+			if text == "" {
+				continue
+			}
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			if skipIndent == nil {
+				skipIndent = &indent
+			} else if text == "}" && indent == *skipIndent {
+				skipping = false
+			}
+			continue
+		case !started:
+			if info.Tool == "" {
+				info.Tool = toolFromLine(text)
+			}
+			if strings.Contains(text, openTofuChangesStart) {
+				line = tfplanparse.CHANGES_START_STRING
+			}
+			if strings.Contains(line, tfplanparse.CHANGES_START_STRING) {
+				started = true
+			} else if containsAny(text, noChangesStrings) {
+				line = tfplanparse.NO_CHANGES_STRING
+				info.Summary = &Summary{}
+			}
+		case summaryPattern.MatchString(text):
+			info.Summary = parseSummary(text)
+		case strings.HasPrefix(text, "#"):
+			comment, kind := normalizeComment(text)
+			switch kind {
+			case annotationComment:
+				continue
+			case unchangedResourceComment:
+				skipping = true
+				skipIndent = nil
+				continue
+			}
+			line = "  " + comment
+		default:
+			line = normalizeAttributeLine(line, text)
+			heredoc = tfplanparse.IsHeredocAttributeChangeLine(line)
+			heredocMarker = -1
+			if heredoc && strings.HasPrefix(text, "~") {
+				// the markers are indented two more than the contents, which are indented four more than the attribute
+				heredocMarker = len(line) - len(strings.TrimLeft(line, " \t")) + 4
+			}
+		}
+
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, info, err
+	}
+
+	return &out, info, nil
+}
+
+// toolFromLine returns the tool that printed the line, or empty if any tool could have printed it
+func toolFromLine(text string) string {
+	for _, p := range toolPrefixes {
+		if strings.HasPrefix(text, p.prefix) {
+			return p.tool
+		}
+	}
+	return ""
+}
+
+// parseSummary parses a line such as "Plan: 1 to add, 2 to change, 3 to destroy."
+func parseSummary(text string) *Summary {
+	match := summaryPattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+
+	// the pattern only matches digits, so these cannot fail
+	add, _ := strconv.Atoi(match[1])
+	change, _ := strconv.Atoi(match[2])
+	remove, _ := strconv.Atoi(match[3])
+	return &Summary{
+		Add:    add,
+		Change: change,
+		Remove: remove,
+	}
+}
+
+type commentKind int
+
+const (
+	// resourceComment describes a planned change to a resource
+	resourceComment commentKind = iota
+
+	// unchangedResourceComment describes a resource without a planned change, such as a moved resource
+	unchangedResourceComment
+
+	// annotationComment is any other comment, such as "# (2 unchanged attributes hidden)" or "# (moved from aws_instance.a)"
+	annotationComment
+)
+
+// normalizeComment returns the comment in the terraform 0.12 format, and what kind of comment it is
+func normalizeComment(comment string) (string, commentKind) {
+	for _, p := range unchangedPatterns {
+		if p.MatchString(comment) {
+			return comment, unchangedResourceComment
+		}
+	}
+
+	comment = deposedPattern.ReplaceAllString(comment, "")
+	for _, s := range replacedSuffixes {
+		if strings.HasSuffix(comment, s) {
+			comment = strings.TrimSuffix(comment, s) + tfplanparse.RESOURCE_REPLACED
+		}
+	}
+
+	for _, s := range changeSuffixes {
+		if strings.HasSuffix(comment, s) {
+			return comment, resourceComment
+		}
+	}
+
+	return comment, annotationComment
+}
+
+func normalizeAttributeLine(line, text string) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+	// blocks whose contents are unknown
+	// Example: + root_block_device (known after apply)
+	if m := knownAfterApply.FindStringSubmatch(text); m != nil {
+		return indent + m[1] + " " + m[2] + " = " + tfplanparse.COMPUTED_VALUE
+	}
+
+	// multiline attributes that force replacement
+	// Example: ~ tags = { # forces replacement
+	if trimmed := strings.TrimSuffix(text, " # forces replacement"); trimmed != text && isMultilineAttributeStart(trimmed) {
+		text = trimmed
+	}
+
+	// terraform 0.14 and later use <<-EOT instead of <<~EOT
+	text = strings.Replace(text, " = <<-EOT", " = <<~EOT", 1)
+
+	return indent + text
+}
+
+// markHeredocLine marks a line of an updated heredoc that was removed or added, with the marker at the column
+func markHeredocLine(line string, marker int) string {
+	if len(line) < marker+2 || strings.TrimSpace(line[:marker]) != "" {
+		return line
+	}
+
+	switch line[marker : marker+2] {
+	case "- ":
+		return heredocRemoved + strings.TrimSpace(line[marker+2:])
+	case "+ ":
+		return heredocAdded + strings.TrimSpace(line[marker+2:])
+	}
+	return line
+}
+
+// splitHeredocLines separates the lines of the updated heredocs in the attribute change into the values before and after the change
+func splitHeredocLines(change interface{}) {
+	switch c := change.(type) {
+	case *tfplanparse.HeredocAttributeChange:
+		if c.UpdateType == tfplanparse.NewResource || c.UpdateType == tfplanparse.DestroyResource {
+			return
+		}
+		lines := c.Before
+		c.Before, c.After = []string{}, []string{}
+		for _, l := range lines {
+			switch {
+			case strings.HasPrefix(l, heredocRemoved):
+				c.Before = append(c.Before, strings.TrimPrefix(l, heredocRemoved))
+			case strings.HasPrefix(l, heredocAdded):
+				c.After = append(c.After, strings.TrimPrefix(l, heredocAdded))
+			default:
+				c.Before = append(c.Before, l)
+				c.After = append(c.After, l)
+			}
+		}
+	case *tfplanparse.MapAttributeChange:
+		for _, a := range c.AttributeChanges {
+			splitHeredocLines(a)
+		}
+	case *tfplanparse.ArrayAttributeChange:
+		for _, a := range c.AttributeChanges {
+			splitHeredocLines(a)
+		}
+	}
+}
+
+func isMultilineAttributeStart(text string) bool {
+	return strings.HasSuffix(text, "{") || strings.HasSuffix(text, "[") || strings.HasSuffix(text, "(")
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"fmt"
+)
+
+// Plan contains the resource changes parsed from an input,
+// along with any plan level information the input format provides
+type Plan struct {
+	ResourceChanges []ResourceChange
+
+	// Variables contains the root module input variables the plan was run with
+	// Only available for JSON plans
+	Variables map[string]interface{}
+
+	// Configuration contains the configuration the plan was made from
+	// Only available for JSON plans
+	Configuration *Configuration
+
+	// Summary is the number of changes the input reports, if the input includes a summary
+	Summary *Summary
+
+	// Tool is the tool that made the plan, either ToolTerraform or ToolOpenTofu
+	// Empty if the input does not identify the tool
+	Tool string
+
+	// ToolVersion is the version of the tool that made the plan, if the input includes it
+	ToolVersion string
+
+	// Drift contains the resources that changed outside of the tool since they were last applied
+	// They are not changed by the plan, and are only available for the machine readable plan output
+	Drift []ResourceChange
+
+	// ValuesOmitted describes why the input leaves out the values of some resources, or is nil if it includes them
+	// Only set for CloudFormation change sets described without --include-property-values
+	ValuesOmitted error
+}
+
+// Summary is the number of changes in a plan
+// Replaced resources count as both an add and a remove
+type Summary struct {
+	Add    int
+	Change int
+	Remove int
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d to add, %d to change, %d to destroy", s.Add, s.Change, s.Remove)
+}
+
+type Configuration struct {
+	// ToolVersion is the version of the tool that made the plan
+	ToolVersion string
+
+	// Tool is the tool that made the plan, either ToolTerraform or ToolOpenTofu
+	Tool string
+
+	Providers   []ProviderConfig
+	ModuleCalls []ModuleCall
+}
+
+type ProviderConfig struct {
+	Name  string
+	Alias string
+
+	// ModuleAddress is empty for providers configured in the root module
+	ModuleAddress     string
+	VersionConstraint string
+}
+
+type ModuleCall struct {
+	// Address is the absolute address of the module call
+	// Example: module.network.module.subnets
+	Address           string
+	Source            string
+	VersionConstraint string
+}
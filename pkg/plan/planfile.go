@@ -0,0 +1,96 @@
+package plan
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// zipMagic is the header of a zip archive, which is the format of plans saved with "terraform plan -out"
+var zipMagic = []byte("PK\x03\x04")
+
+// IsBinaryPlanFile returns true if the file is a plan saved with "terraform plan -out"
+func IsBinaryPlanFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(zipMagic))
+	if _, err := io.ReadFull(f, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return bytes.Equal(header, zipMagic), nil
+}
+
+// NewPlanFromPlanFile decodes a plan saved with "terraform plan -out" by running "<bin> show -json <path>"
+// bin can be any executable that is compatible with "terraform show -json", such as "tofu"
+func NewPlanFromPlanFile(ctx context.Context, bin, path string) (*Plan, error) {
+	var changes []ResourceChange
+	result, err := DecodePlanFile(ctx, bin, path, func(rc ResourceChange) error {
+		changes = append(changes, rc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.ResourceChanges = changes
+
+	return result, nil
+}
+
+// DecodePlanFile decodes a plan saved with "terraform plan -out" like NewPlanFromPlanFile,
+// calling fn with each resource change as it is read like DecodeJSON
+func DecodePlanFile(ctx context.Context, bin, path string, fn func(ResourceChange) error) (*Plan, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "show", "-json", path)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s show -json %s: %v", bin, path, err)
+	}
+
+	result, err := DecodeJSON(stdout, fn)
+	if err != nil {
+		// stop the command, as the rest of its output will not be read
+		cmd.Process.Kill()
+		waitErr := cmd.Wait()
+
+		// a command that failed on its own writes no plan, so report why it failed instead
+		// Otherwise the command was stopped because of err, which is returned unchanged
+		failed := waitErr != nil && cmd.ProcessState.Exited()
+		if failed || ctx.Err() == context.DeadlineExceeded {
+			return nil, showError(ctx, bin, path, waitErr, stderr.String())
+		}
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, showError(ctx, bin, path, err, stderr.String())
+	}
+
+	return result, nil
+}
+
+// showError describes a failed run of "<bin> show -json <path>"
+func showError(ctx context.Context, bin, path string, err error, stderr string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out running %s show -json %s", bin, path)
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("failed to run %s show -json %s: %v: %s", bin, path, err, msg)
+	}
+	return fmt.Errorf("failed to run %s show -json %s: %v", bin, path, err)
+}
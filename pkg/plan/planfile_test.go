@@ -0,0 +1,180 @@
+package plan
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFakeTerraform writes an executable shell script that stands in for terraform
+func writeFakeTerraform(t *testing.T, dir, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake terraform binary requires a POSIX shell")
+	}
+
+	bin := filepath.Join(dir, "terraform")
+	if err := ioutil.WriteFile(bin, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+func writeBinaryPlan(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "plan.out")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	if _, err := w.Create("tfplan"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsBinaryPlanFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "akashi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	empty := filepath.Join(dir, "empty")
+	if err := ioutil.WriteFile(empty, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		path     string
+		expected bool
+	}{
+		"binary plan": {
+			path:     writeBinaryPlan(t, dir),
+			expected: true,
+		},
+		"JSON plan": {
+			path:     "testdata/plan.json",
+			expected: false,
+		},
+		"empty file": {
+			path:     empty,
+			expected: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := IsBinaryPlanFile(tc.path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromPlanFile(t *testing.T) {
+	fixture, err := filepath.Abs("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		script        string
+		timeout       time.Duration
+		expectedError string
+	}{
+		"decodes the plan": {
+			script: fmt.Sprintf(`[ "$1" = "show" ] && [ "$2" = "-json" ] || exit 2
+cat %q`, fixture),
+			timeout: 10 * time.Second,
+		},
+		"terraform fails": {
+			script:        `echo "Failed to load plan" >&2; exit 1`,
+			timeout:       10 * time.Second,
+			expectedError: "Failed to load plan",
+		},
+		"terraform times out": {
+			script:        `exec sleep 5`,
+			timeout:       100 * time.Millisecond,
+			expectedError: "timed out",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "akashi")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			bin := writeFakeTerraform(t, dir, tc.script)
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			p, err := NewPlanFromPlanFile(ctx, bin, writeBinaryPlan(t, dir))
+			if tc.expectedError != "" {
+				if err == nil {
+					t.Fatalf("Expected an error but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("Expected error to contain %q but got %q", tc.expectedError, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(p.ResourceChanges) != 3 {
+				t.Errorf("Expected 3 resource changes but got %d", len(p.ResourceChanges))
+			}
+		})
+	}
+}
+
+func TestDecodePlanFileCallbackError(t *testing.T) {
+	fixture, err := filepath.Abs("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "akashi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// terraform writes warnings to stderr while still writing the plan
+	bin := writeFakeTerraform(t, dir, fmt.Sprintf(`echo "Warning: deprecated attribute" >&2
+cat %q
+exec sleep 5`, fixture))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stop := errors.New("stop")
+	_, err = DecodePlanFile(ctx, bin, writeBinaryPlan(t, dir), func(ResourceChange) error {
+		return stop
+	})
+	if err != stop {
+		t.Errorf("Expected: %v but got %v", stop, err)
+	}
+}
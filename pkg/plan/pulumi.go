@@ -0,0 +1,244 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+)
+
+const (
+	pulumiOpSame              = "same"
+	pulumiOpCreate            = "create"
+	pulumiOpUpdate            = "update"
+	pulumiOpDelete            = "delete"
+	pulumiOpReplace           = "replace"
+	pulumiOpCreateReplacement = "create-replacement"
+	pulumiOpDeleteReplaced    = "delete-replaced"
+	pulumiOpRead              = "read"
+)
+
+// pulumiUnknown is the value pulumi uses for values that are not known until the update
+const pulumiUnknown = "04da6b54-80e4-46f7-96ec-b56ff0331ba9"
+
+// pulumiPreview is the output of "pulumi preview --json"
+type pulumiPreview struct {
+	Steps         []*pulumiStep  `json:"steps"`
+	ChangeSummary map[string]int `json:"changeSummary"`
+	Diagnostics   []struct {
+		Message  string `json:"message"`
+		Severity string `json:"severity"`
+	} `json:"diagnostics"`
+}
+
+type pulumiStep struct {
+	Op       string       `json:"op"`
+	URN      string       `json:"urn"`
+	OldState *pulumiState `json:"oldState,omitempty"`
+	NewState *pulumiState `json:"newState,omitempty"`
+}
+
+type pulumiState struct {
+	Type   string                 `json:"type"`
+	Inputs map[string]interface{} `json:"inputs"`
+}
+
+// pulumiStepChange is a step of a pulumi preview, whose values are the inputs of the resource
+type pulumiStepChange struct {
+	Step *pulumiStep
+}
+
+// NewPlanFromPulumiPreview reads the output of "pulumi preview --json"
+// The change summary is required, and is compared against the steps to verify the output is complete
+func NewPlanFromPulumiPreview(in io.Reader) (*Plan, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var preview pulumiPreview
+	if err := json.Unmarshal(data, &preview); err != nil {
+		return nil, err
+	}
+
+	for _, d := range preview.Diagnostics {
+		if d.Severity == "error" {
+			return nil, fmt.Errorf("preview failed: %s", strings.TrimSpace(d.Message))
+		}
+	}
+
+	result := &Plan{
+		Tool: ToolPulumi,
+	}
+	var parsed Summary
+	for i, step := range preview.Steps {
+		if step == nil || step.URN == "" {
+			return nil, fmt.Errorf("step %d is missing the urn", i)
+		}
+		// replacements are also reported as a single "replace" step, which is used instead
+		if step.Op == pulumiOpCreateReplacement || step.Op == pulumiOpDeleteReplaced {
+			continue
+		}
+		result.ResourceChanges = append(result.ResourceChanges, newPulumiStepChange(step))
+		parsed = addPulumiOp(parsed, step.Op)
+	}
+
+	if preview.ChangeSummary == nil {
+		return nil, fmt.Errorf("preview has no change summary, it may be incomplete")
+	}
+	result.Summary = &Summary{
+		Add:    preview.ChangeSummary[pulumiOpCreate] + preview.ChangeSummary[pulumiOpReplace],
+		Change: preview.ChangeSummary[pulumiOpUpdate],
+		Remove: preview.ChangeSummary[pulumiOpDelete] + preview.ChangeSummary[pulumiOpReplace],
+	}
+	if *result.Summary != parsed {
+		return nil, fmt.Errorf("preview reports %s but %s were parsed", result.Summary, parsed)
+	}
+
+	return result, nil
+}
+
+// addPulumiOp adds the op to the summary, counting replacements as both an add and a remove
+func addPulumiOp(s Summary, op string) Summary {
+	switch op {
+	case pulumiOpCreate:
+		s.Add++
+	case pulumiOpUpdate:
+		s.Change++
+	case pulumiOpDelete:
+		s.Remove++
+	case pulumiOpReplace:
+		s.Add++
+		s.Remove++
+	}
+
+	return s
+}
+
+// parseURN splits a URN such as "urn:pulumi:dev::project::aws:s3/bucket:Bucket::logs" into its type and name
+func parseURN(urn string) (string, string) {
+	parts := strings.SplitN(urn, "::", 4)
+	if len(parts) != 4 {
+		return "", ""
+	}
+
+	qualifiedType := parts[2]
+	if i := strings.LastIndex(qualifiedType, "$"); i >= 0 {
+		qualifiedType = qualifiedType[i+1:]
+	}
+	return qualifiedType, parts[3]
+}
+
+func newPulumiStepChange(step *pulumiStep) ResourceChange {
+	return &pulumiStepChange{
+		Step: step,
+	}
+}
+
+func (p *pulumiStepChange) IsCreate() bool {
+	return p.Step.Op == pulumiOpCreate || p.Step.Op == pulumiOpReplace
+}
+
+func (p *pulumiStepChange) IsDelete() bool {
+	return p.Step.Op == pulumiOpDelete || p.Step.Op == pulumiOpReplace
+}
+
+func (p *pulumiStepChange) IsNoOp() bool {
+	return p.Step.Op == pulumiOpSame
+}
+
+func (p *pulumiStepChange) IsUpdate() bool {
+	return p.Step.Op == pulumiOpUpdate
+}
+
+func (p *pulumiStepChange) IsRead() bool {
+	return p.Step.Op == pulumiOpRead
+}
+
+func (p *pulumiStepChange) GetBefore() map[string]interface{} {
+	return pulumiInputs(p.Step.OldState)
+}
+
+// GetAfter returns the inputs of the new state, without the inputs that are not known until the update
+func (p *pulumiStepChange) GetAfter() map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range pulumiInputs(p.Step.NewState) {
+		if v != pulumiUnknown {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func (p *pulumiStepChange) GetBeforeChangedOnly() map[string]interface{} {
+	return p.changedOnly(p.GetBefore())
+}
+
+func (p *pulumiStepChange) GetAfterChangedOnly() map[string]interface{} {
+	return p.changedOnly(p.GetAfter())
+}
+
+// GetComputed returns the inputs that are not known until the update
+func (p *pulumiStepChange) GetComputed() map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range pulumiInputs(p.Step.NewState) {
+		if v == pulumiUnknown {
+			result[k] = true
+		}
+	}
+	return result
+}
+
+func (p *pulumiStepChange) GetName() string {
+	_, name := parseURN(p.Step.URN)
+	return name
+}
+
+// GetType prefers the type of the state, and falls back to the type in the URN
+func (p *pulumiStepChange) GetType() string {
+	if p.Step.NewState != nil && p.Step.NewState.Type != "" {
+		return p.Step.NewState.Type
+	}
+	if p.Step.OldState != nil && p.Step.OldState.Type != "" {
+		return p.Step.OldState.Type
+	}
+	t, _ := parseURN(p.Step.URN)
+	return t
+}
+
+// GetAddress returns the URN, which uniquely identifies the resource
+func (p *pulumiStepChange) GetAddress() string {
+	return p.Step.URN
+}
+
+// GetMode returns DataMode for reads of external resources, to match data sources
+func (p *pulumiStepChange) GetMode() string {
+	if p.Step.Op == pulumiOpRead {
+		return DataMode
+	}
+	return ManagedMode
+}
+
+// changedOnly returns the values of the inputs that differ between the old and new state
+func (p *pulumiStepChange) changedOnly(values map[string]interface{}) map[string]interface{} {
+	before, after := pulumiInputs(p.Step.OldState), pulumiInputs(p.Step.NewState)
+
+	result := map[string]interface{}{}
+	for k, v := range values {
+		old, inBefore := before[k]
+		updated, inAfter := after[k]
+		if !inBefore || !inAfter || !reflect.DeepEqual(old, updated) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func pulumiInputs(state *pulumiState) map[string]interface{} {
+	if state == nil || state.Inputs == nil {
+		return map[string]interface{}{}
+	}
+	return state.Inputs
+}
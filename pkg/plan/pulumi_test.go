@@ -0,0 +1,226 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPlanFromPulumiPreview(t *testing.T) {
+	f, err := os.Open("testdata/pulumi-preview.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := NewPlanFromPulumiPreview(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []goldenResourceChange
+	for _, rc := range p.ResourceChanges {
+		got = append(got, newGoldenResourceChange(rc))
+	}
+
+	expected := []goldenResourceChange{
+		{
+			Address:  "urn:pulumi:dev::storage::pulumi:pulumi:Stack::storage-dev",
+			Type:     "pulumi:pulumi:Stack",
+			Name:     "storage-dev",
+			Mode:     ManagedMode,
+			Action:   "no-op",
+			Before:   map[string]interface{}{},
+			After:    map[string]interface{}{},
+			Computed: map[string]interface{}{},
+		},
+		{
+			Address: "urn:pulumi:dev::storage::aws:s3/bucket:Bucket::logs",
+			Type:    "aws:s3/bucket:Bucket",
+			Name:    "logs",
+			Mode:    ManagedMode,
+			Action:  "create",
+			Before:  map[string]interface{}{},
+			After: map[string]interface{}{
+				"acl":          "private",
+				"forceDestroy": false,
+			},
+			Computed: map[string]interface{}{
+				"bucket": true,
+			},
+		},
+		{
+			Address: "urn:pulumi:dev::storage::aws:s3/bucket:Bucket::assets",
+			Type:    "aws:s3/bucket:Bucket",
+			Name:    "assets",
+			Mode:    ManagedMode,
+			Action:  "update",
+			Before: map[string]interface{}{
+				"acl":  "private",
+				"tags": map[string]interface{}{"team": "web"},
+			},
+			After: map[string]interface{}{
+				"acl":  "public-read",
+				"tags": map[string]interface{}{"team": "web"},
+			},
+			Computed: map[string]interface{}{},
+		},
+		{
+			Address:  "urn:pulumi:dev::storage::aws:s3/bucket:Bucket$aws:s3/bucketObject:BucketObject::index",
+			Type:     "aws:s3/bucketObject:BucketObject",
+			Name:     "index",
+			Mode:     ManagedMode,
+			Action:   "replace",
+			Before:   map[string]interface{}{"key": "index.htm"},
+			After:    map[string]interface{}{"key": "index.html"},
+			Computed: map[string]interface{}{},
+		},
+		{
+			Address:  "urn:pulumi:dev::storage::aws:s3/bucket:Bucket::old",
+			Type:     "aws:s3/bucket:Bucket",
+			Name:     "old",
+			Mode:     ManagedMode,
+			Action:   "delete",
+			Before:   map[string]interface{}{"acl": "private"},
+			After:    map[string]interface{}{},
+			Computed: map[string]interface{}{},
+		},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Resource changes mismatch (-expected +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(map[string]interface{}{"acl": "private"}, p.ResourceChanges[2].GetBeforeChangedOnly()); diff != "" {
+		t.Errorf("Changed values mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]interface{}{"acl": "public-read"}, p.ResourceChanges[2].GetAfterChangedOnly()); diff != "" {
+		t.Errorf("Changed values mismatch (-expected +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(&Summary{Add: 2, Change: 1, Remove: 2}, p.Summary); diff != "" {
+		t.Errorf("Summary mismatch (-expected +got):\n%s", diff)
+	}
+	if p.Tool != ToolPulumi {
+		t.Errorf("Expected tool %q but got %q", ToolPulumi, p.Tool)
+	}
+}
+
+func TestPulumiStepChangeChangedOnly(t *testing.T) {
+	cases := map[string]struct {
+		step           *pulumiStep
+		expectedBefore map[string]interface{}
+		expectedAfter  map[string]interface{}
+	}{
+		"unchanged inputs are left out": {
+			step: &pulumiStep{
+				Op:       pulumiOpUpdate,
+				OldState: &pulumiState{Inputs: map[string]interface{}{"acl": "private", "tags": map[string]interface{}{"team": "web"}}},
+				NewState: &pulumiState{Inputs: map[string]interface{}{"acl": "private", "tags": map[string]interface{}{"team": "data"}}},
+			},
+			expectedBefore: map[string]interface{}{"tags": map[string]interface{}{"team": "web"}},
+			expectedAfter:  map[string]interface{}{"tags": map[string]interface{}{"team": "data"}},
+		},
+		"added and removed inputs": {
+			step: &pulumiStep{
+				Op:       pulumiOpUpdate,
+				OldState: &pulumiState{Inputs: map[string]interface{}{"acl": "private", "website": "index.html"}},
+				NewState: &pulumiState{Inputs: map[string]interface{}{"acl": "private", "versioning": true}},
+			},
+			expectedBefore: map[string]interface{}{"website": "index.html"},
+			expectedAfter:  map[string]interface{}{"versioning": true},
+		},
+		"unknown inputs are left out of the after values": {
+			step: &pulumiStep{
+				Op:       pulumiOpUpdate,
+				OldState: &pulumiState{Inputs: map[string]interface{}{"bucket": "assets"}},
+				NewState: &pulumiState{Inputs: map[string]interface{}{"bucket": pulumiUnknown}},
+			},
+			expectedBefore: map[string]interface{}{"bucket": "assets"},
+			expectedAfter:  map[string]interface{}{},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rc := newPulumiStepChange(tc.step)
+			if diff := cmp.Diff(tc.expectedBefore, rc.GetBeforeChangedOnly()); diff != "" {
+				t.Errorf("Before mismatch (-expected +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.expectedAfter, rc.GetAfterChangedOnly()); diff != "" {
+				t.Errorf("After mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromPulumiPreviewErrors(t *testing.T) {
+	cases := map[string]struct {
+		input string
+		err   string
+	}{
+		"missing change summary": {
+			input: `{"steps":[{"op":"create","urn":"urn:pulumi:dev::storage::aws:s3/bucket:Bucket::logs"}]}`,
+			err:   "no change summary",
+		},
+		"summary mismatch": {
+			input: `{"steps":[{"op":"create","urn":"urn:pulumi:dev::storage::aws:s3/bucket:Bucket::logs"}],"changeSummary":{"create":2}}`,
+			err:   "reports 2 to add, 0 to change, 0 to destroy but 1 to add, 0 to change, 0 to destroy were parsed",
+		},
+		"missing urn": {
+			input: `{"steps":[{"op":"create"}],"changeSummary":{"create":1}}`,
+			err:   "step 0 is missing the urn",
+		},
+		"failed preview": {
+			input: `{"steps":[],"diagnostics":[{"message":"error: missing required configuration variable 'aws:region'\n","severity":"error"}]}`,
+			err:   "preview failed: error: missing required configuration variable 'aws:region'",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPlanFromPulumiPreview(strings.NewReader(tc.input))
+			if err == nil {
+				t.Fatalf("Expected an error but got none")
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected error to contain %q but got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseURN(t *testing.T) {
+	cases := map[string]struct {
+		urn          string
+		expectedType string
+		expectedName string
+	}{
+		"resource": {
+			urn:          "urn:pulumi:dev::storage::aws:s3/bucket:Bucket::logs",
+			expectedType: "aws:s3/bucket:Bucket",
+			expectedName: "logs",
+		},
+		"child resource": {
+			urn:          "urn:pulumi:dev::storage::my:component:Site$aws:s3/bucket:Bucket::site-bucket",
+			expectedType: "aws:s3/bucket:Bucket",
+			expectedName: "site-bucket",
+		},
+		"invalid urn": {
+			urn: "logs",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotType, gotName := parseURN(tc.urn)
+			if gotType != tc.expectedType {
+				t.Errorf("Expected type %q but got %q", tc.expectedType, gotType)
+			}
+			if gotName != tc.expectedName {
+				t.Errorf("Expected name %q but got %q", tc.expectedName, gotName)
+			}
+		})
+	}
+}
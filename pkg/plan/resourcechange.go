@@ -1,10 +1,23 @@
 package plan
 
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	ManagedMode = "managed"
+	DataMode    = "data"
+)
+
+// ResourceChange is a change to a single resource, read from any of the input formats
+// A replacement destroys the resource and creates it again, so IsCreate and IsDelete both return true and IsUpdate returns false
 type ResourceChange interface {
 	IsCreate() bool
 	IsDelete() bool
 	IsNoOp() bool
 	IsUpdate() bool
+	IsRead() bool
 	GetBefore() map[string]interface{}
 	GetAfter() map[string]interface{}
 	GetBeforeChangedOnly() map[string]interface{}
@@ -13,4 +26,34 @@ type ResourceChange interface {
 	GetName() string
 	GetType() string
 	GetAddress() string
+
+	// GetMode returns "managed" for resources, and "data" for data sources
+	GetMode() string
+}
+
+// modeFromAddress returns the mode of the resource at the absolute address
+func modeFromAddress(address string) string {
+	// remove the index first in case the index contains a "."
+	address = strings.Split(address, "[")[0]
+	values := strings.Split(address, ".")
+
+	// data.type.name, where "data" is not the name of a module
+	if len(values) >= 3 && values[len(values)-3] == "data" && (len(values) == 3 || values[len(values)-4] != "module") {
+		return DataMode
+	}
+
+	return ManagedMode
+}
+
+// memoValues holds values of a resource change that are only extracted once, and must not be modified
+type memoValues struct {
+	once   sync.Once
+	values map[string]interface{}
+}
+
+func (m *memoValues) get(extract func() map[string]interface{}) map[string]interface{} {
+	m.once.Do(func() {
+		m.values = extract()
+	})
+	return m.values
 }
@@ -0,0 +1,67 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// terragruntLinePattern matches a line of "terragrunt run-all plan" output that is prefixed with the module it came from
+// Example: [live/prod/vpc] Plan: 1 to add, 0 to change, 0 to destroy.
+// Example: 10:04:05.123 STDOUT [live/prod/vpc] terraform: Plan: 1 to add, 0 to change, 0 to destroy.
+var terragruntLinePattern = regexp.MustCompile(`^(?:\d{2}:\d{2}:\d{2}(?:\.\d+)? )?(?:(STDOUT|STDERR) +)?\[([^\]]+)\] ?(?:(?:terraform|tofu): )?(.*)$`)
+
+// terragruntLogPrefix is the prefix of the log lines of older versions of terragrunt, which are not module output
+const terragruntLogPrefix = "terragrunt"
+
+// TerragruntModule is the plan output of a single module in the output of "terragrunt run-all plan"
+type TerragruntModule struct {
+	// Path is the path of the module, as printed by terragrunt
+	Path string
+
+	// Output is the plan output of the module without the prefixes
+	Output []byte
+}
+
+// SplitTerragruntOutput demultiplexes the interleaved output of "terragrunt run-all plan" into the output of each module
+// Modules are returned in the order their first line appears, and lines without a module prefix or written to stderr are dropped
+func SplitTerragruntOutput(in io.Reader) ([]*TerragruntModule, error) {
+	var result []*TerragruntModule
+	modules := make(map[string]*TerragruntModule)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	for scanner.Scan() {
+		line := ansiPattern.ReplaceAllString(scanner.Text(), "")
+		match := terragruntLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		stream, path, text := match[1], match[2], match[3]
+		if stream == "STDERR" || path == terragruntLogPrefix {
+			continue
+		}
+
+		m, ok := modules[path]
+		if !ok {
+			m = &TerragruntModule{
+				Path: path,
+			}
+			modules[path] = m
+			result = append(result, m)
+		}
+		m.Output = append(m.Output, text...)
+		m.Output = append(m.Output, '\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// NewPlan parses the plan output of the module
+func (m *TerragruntModule) NewPlan() (*Plan, error) {
+	return NewPlanFromPlanOutput(bytes.NewReader(m.Output))
+}
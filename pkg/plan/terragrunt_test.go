@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitTerragruntOutput(t *testing.T) {
+	f, err := os.Open("testdata/terragrunt-run-all.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	modules, err := SplitTerragruntOutput(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var paths []string
+	for _, m := range modules {
+		paths = append(paths, m.Path)
+	}
+	if diff := cmp.Diff([]string{"live/prod/vpc", "live/prod/app"}, paths); diff != "" {
+		t.Fatalf("Modules mismatch (-expected +got):\n%s", diff)
+	}
+
+	vpc, err := modules[0].NewPlan()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(vpc.ResourceChanges) != 0 {
+		t.Errorf("Expected no resource changes for live/prod/vpc but got %d", len(vpc.ResourceChanges))
+	}
+
+	app, err := modules[1].NewPlan()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(app.ResourceChanges) != 1 || app.ResourceChanges[0].GetAddress() != "google_storage_bucket.uploads" {
+		t.Errorf("Expected live/prod/app to create google_storage_bucket.uploads")
+	}
+	if strings.Contains(string(modules[1].Output), "Warning") {
+		t.Errorf("Expected stderr lines to be dropped")
+	}
+}
+
+func TestSplitTerragruntOutputLineFormats(t *testing.T) {
+	input := strings.Join([]string{
+		"10:04:05.123 STDOUT [live/vpc] terraform: Plan: 1 to add, 0 to change, 0 to destroy.",
+		"10:04:05.123 STDOUT [live/db] tofu: No changes.",
+		"\x1b[0m[live/app]   # google_storage_bucket.a will be created",
+		"[terragrunt] 2023/10/02 10:00:00 Running command: terraform plan",
+		"unprefixed line",
+	}, "\n")
+
+	modules, err := SplitTerragruntOutput(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, m := range modules {
+		got[m.Path] = string(m.Output)
+	}
+	expected := map[string]string{
+		"live/vpc": "Plan: 1 to add, 0 to change, 0 to destroy.\n",
+		"live/db":  "No changes.\n",
+		"live/app": "  # google_storage_bucket.a will be created\n",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Output mismatch (-expected +got):\n%s", diff)
+	}
+}
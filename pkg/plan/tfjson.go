@@ -1,8 +1,11 @@
 package plan
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/terraform-json"
 )
@@ -12,26 +15,221 @@ type jsonPlanChange struct {
 }
 
 func NewResourcePlanFromJSON(in io.Reader) ([]ResourceChange, error) {
-	var result []ResourceChange
-
-	parsed := &tfjson.Plan{}
-	data, err := ioutil.ReadAll(in)
+	p, err := NewPlanFromJSON(in)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 
-	err = parsed.UnmarshalJSON(data)
+	return p.ResourceChanges, nil
+}
+
+// NewPlanFromJSON reads the output of "terraform show -json" or "tofu show -json" for a saved plan
+func NewPlanFromJSON(in io.Reader) (*Plan, error) {
+	var changes []ResourceChange
+	result, err := DecodeJSON(in, func(rc ResourceChange) error {
+		changes = append(changes, rc)
+		return nil
+	})
 	if err != nil {
-		return result, err
+		return nil, err
 	}
+	result.ResourceChanges = changes
 
-	for _, rc := range parsed.ResourceChanges {
-		result = append(result, newJSONPlanChange(rc))
+	return result, nil
+}
+
+// DecodeJSON reads the output of "terraform show -json" for a saved plan, calling fn with each resource change as it is read
+// The returned plan has no resource changes, and an error from fn is returned as is
+func DecodeJSON(in io.Reader, fn func(ResourceChange) error) (*Plan, error) {
+	dec := json.NewDecoder(in)
+	if err := expectJSONDelim(dec, '{'); err != nil {
+		return nil, err
 	}
 
+	var formatVersion, terraformVersion string
+	var variables map[string]*tfjson.PlanVariable
+	var config *tfjson.Config
+	var hasProviders, isOpenTofu bool
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token {
+		case "format_version":
+			err = dec.Decode(&formatVersion)
+		case "terraform_version":
+			err = dec.Decode(&terraformVersion)
+		case "variables":
+			err = dec.Decode(&variables)
+		case "configuration":
+			err = dec.Decode(&config)
+		case "resource_changes":
+			// tfjson only accepts format version 0.1, so the version is checked here instead
+			// to accept the newer versions made by terraform 1.x and OpenTofu
+			// terraform always writes the version first, so changes are not read from unsupported formats
+			if err := checkFormatVersion(formatVersion); err != nil {
+				return nil, err
+			}
+			err = decodeJSONArray(dec, func() error {
+				rc := &tfjson.ResourceChange{}
+				if err := dec.Decode(rc); err != nil {
+					return err
+				}
+				if rc.Change == nil {
+					return fmt.Errorf("resource change %s has no change", rc.Address)
+				}
+
+				// the tool is determined the same way as toolFromProviderNames, without keeping every name
+				hasProviders = true
+				if strings.HasPrefix(rc.ProviderName, openTofuRegistry) {
+					isOpenTofu = true
+				}
+				if isForget(rc.Change.Actions) {
+					return nil
+				}
+				return fn(newJSONPlanChange(rc))
+			})
+		default:
+			err = skipJSONValue(dec)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := expectJSONDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	if err := checkFormatVersion(formatVersion); err != nil {
+		return nil, err
+	}
+
+	result := &Plan{
+		Variables:   make(map[string]interface{}),
+		ToolVersion: terraformVersion,
+	}
+	for k, v := range variables {
+		if v != nil {
+			result.Variables[k] = v.Value
+		}
+	}
+	// plans without resource changes are only identified by the version, which both tools write as terraform_version
+	switch {
+	case isOpenTofu:
+		result.Tool = ToolOpenTofu
+	case hasProviders, terraformVersion != "":
+		result.Tool = ToolTerraform
+	}
+	result.Configuration = newConfiguration(terraformVersion, config)
+	result.Configuration.Tool = result.Tool
+
 	return result, nil
 }
 
+// expectJSONDelim reads the next token and returns an error if it is not the delimiter
+func expectJSONDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("unexpected input, expected %q but got %v", delim, token)
+	}
+	return nil
+}
+
+// decodeJSONArray reads an array from the decoder, calling fn to decode each element in turn
+func decodeJSONArray(dec *json.Decoder, fn func() error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("unexpected input, expected an array but got %v", token)
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(dec, ']')
+}
+
+// isForget returns true if the only action is to remove the resource from the state
+func isForget(actions tfjson.Actions) bool {
+	return len(actions) == 1 && string(actions[0]) == actionForget
+}
+
+func newConfiguration(terraformVersion string, config *tfjson.Config) *Configuration {
+	result := &Configuration{
+		ToolVersion: terraformVersion,
+	}
+	if config == nil {
+		return result
+	}
+
+	for _, p := range config.ProviderConfigs {
+		if p == nil {
+			continue
+		}
+		result.Providers = append(result.Providers, ProviderConfig{
+			Name:              p.Name,
+			Alias:             p.Alias,
+			ModuleAddress:     p.ModuleAddress,
+			VersionConstraint: p.VersionConstraint,
+		})
+	}
+	sort.Slice(result.Providers, func(i, j int) bool {
+		a, b := result.Providers[i], result.Providers[j]
+		if a.ModuleAddress != b.ModuleAddress {
+			return a.ModuleAddress < b.ModuleAddress
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Alias < b.Alias
+	})
+	result.ModuleCalls = appendModuleCalls(result.ModuleCalls, "", config.RootModule)
+
+	return result
+}
+
+// appendModuleCalls recursively appends the module calls of the module at address and its children
+func appendModuleCalls(result []ModuleCall, address string, module *tfjson.ConfigModule) []ModuleCall {
+	if module == nil {
+		return result
+	}
+
+	names := make([]string, 0, len(module.ModuleCalls))
+	for name := range module.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		mc := module.ModuleCalls[name]
+		if mc == nil {
+			continue
+		}
+		callAddress := fmt.Sprintf("module.%s", name)
+		if address != "" {
+			callAddress = fmt.Sprintf("%s.%s", address, callAddress)
+		}
+		result = append(result, ModuleCall{
+			Address:           callAddress,
+			Source:            mc.Source,
+			VersionConstraint: mc.VersionConstraint,
+		})
+		result = appendModuleCalls(result, callAddress, mc.Module)
+	}
+
+	return result
+}
+
 func newJSONPlanChange(json *tfjson.ResourceChange) ResourceChange {
 	return &jsonPlanChange{
 		ResourceChange: json,
@@ -39,11 +237,11 @@ func newJSONPlanChange(json *tfjson.ResourceChange) ResourceChange {
 }
 
 func (j *jsonPlanChange) IsCreate() bool {
-	return j.ResourceChange.Change.Actions.Create()
+	return j.ResourceChange.Change.Actions.Create() || j.ResourceChange.Change.Actions.Replace()
 }
 
 func (j *jsonPlanChange) IsDelete() bool {
-	return j.ResourceChange.Change.Actions.Delete()
+	return j.ResourceChange.Change.Actions.Delete() || j.ResourceChange.Change.Actions.Replace()
 }
 
 func (j *jsonPlanChange) IsNoOp() bool {
@@ -54,6 +252,10 @@ func (j *jsonPlanChange) IsUpdate() bool {
 	return j.ResourceChange.Change.Actions.Update()
 }
 
+func (j *jsonPlanChange) IsRead() bool {
+	return j.ResourceChange.Change.Actions.Read()
+}
+
 func (j *jsonPlanChange) GetBefore() map[string]interface{} {
 	if j.ResourceChange.Change.Before != nil {
 		return j.ResourceChange.Change.Before.(map[string]interface{})
@@ -94,3 +296,7 @@ func (j *jsonPlanChange) GetType() string {
 func (j *jsonPlanChange) GetAddress() string {
 	return j.ResourceChange.Address
 }
+
+func (j *jsonPlanChange) GetMode() string {
+	return string(j.ResourceChange.Mode)
+}
@@ -0,0 +1,93 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// benchmarkResources is the number of resource changes in the generated plan,
+// large enough that holding every change in memory is clearly visible in the peak heap
+const benchmarkResources = 20000
+
+// heapSampleInterval is how often, in resource changes, the heap is sampled
+// Sampling stops the world, so it is not done for every change
+const heapSampleInterval = 500
+
+// generatePlan writes a JSON plan with n resource changes to a pipe, so the plan itself is never held in memory
+func generatePlan(n int) io.Reader {
+	r, w := io.Pipe()
+	go func() {
+		fmt.Fprint(w, `{"format_version":"1.2","terraform_version":"1.7.0","variables":{"environment":{"value":"prod"}},"resource_changes":[`)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"address":"google_compute_instance.web[%d]","mode":"managed","type":"google_compute_instance","name":"web","index":%d,"provider_name":"registry.terraform.io/hashicorp/google",`, i, i)
+			fmt.Fprintf(w, `"change":{"actions":["create"],"before":null,"after":{"name":"web-%d","machine_type":"n1-standard-1","zone":"us-central1-a","labels":{"environment":"prod","team":"platform"},"tags":["web","http","https"]},"after_unknown":{"id":true,"self_link":true}}}`, i)
+		}
+		fmt.Fprint(w, `],"configuration":{"root_module":{}}}`)
+		w.Close()
+	}()
+	return r
+}
+
+// heapSampler records the largest heap seen while a plan is read
+type heapSampler struct {
+	count int
+	peak  uint64
+}
+
+func (h *heapSampler) sample() {
+	h.count++
+	if h.count%heapSampleInterval != 0 {
+		return
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc > h.peak {
+		h.peak = stats.HeapAlloc
+	}
+}
+
+func (h *heapSampler) report(b *testing.B) {
+	b.ReportMetric(float64(h.peak), "peak-heap-bytes")
+}
+
+func BenchmarkNewPlanFromJSON(b *testing.B) {
+	b.ReportAllocs()
+	h := &heapSampler{}
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		p, err := NewPlanFromJSON(generatePlan(benchmarkResources))
+		if err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+
+		// every change is held until the whole plan is read, so evaluating them happens afterwards
+		for _, rc := range p.ResourceChanges {
+			rc.GetAfter()
+			h.sample()
+		}
+	}
+	h.report(b)
+}
+
+func BenchmarkDecodeJSON(b *testing.B) {
+	b.ReportAllocs()
+	h := &heapSampler{}
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		_, err := DecodeJSON(generatePlan(benchmarkResources), func(rc ResourceChange) error {
+			rc.GetAfter()
+			h.sample()
+			return nil
+		})
+		if err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	h.report(b)
+}
@@ -0,0 +1,209 @@
+package plan
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPlanFromJSON(t *testing.T) {
+	f, err := os.Open("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := NewPlanFromJSON(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(p.ResourceChanges) != 3 {
+		t.Errorf("Expected 3 resource changes but got %d", len(p.ResourceChanges))
+	}
+
+	expectedVariables := map[string]interface{}{
+		"environment": "prod",
+		"region":      "us-central1",
+	}
+	if diff := cmp.Diff(expectedVariables, p.Variables); diff != "" {
+		t.Errorf("Variables mismatch (-expected +got):\n%s", diff)
+	}
+
+	expectedConfiguration := &Configuration{
+		ToolVersion: "0.12.29",
+		Tool:        ToolTerraform,
+		Providers: []ProviderConfig{
+			{Name: "google", VersionConstraint: "~> 3.40"},
+			{Name: "google", Alias: "west"},
+		},
+		ModuleCalls: []ModuleCall{
+			{Address: "module.network", Source: "terraform-google-modules/network/google", VersionConstraint: "2.5.0"},
+			{Address: "module.network.module.subnets", Source: "./modules/subnets"},
+		},
+	}
+	if diff := cmp.Diff(expectedConfiguration, p.Configuration); diff != "" {
+		t.Errorf("Configuration mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	f, err := os.Open("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var addresses []string
+	p, err := DecodeJSON(f, func(rc ResourceChange) error {
+		addresses = append(addresses, rc.GetAddress())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(p.ResourceChanges) != 0 {
+		t.Errorf("Expected no resource changes to be held but got %d", len(p.ResourceChanges))
+	}
+	if len(addresses) != 3 {
+		t.Errorf("Expected 3 resource changes but got %d", len(addresses))
+	}
+	if p.Tool != ToolTerraform {
+		t.Errorf("Expected tool %q but got %q", ToolTerraform, p.Tool)
+	}
+	if p.Configuration == nil || len(p.Variables) == 0 {
+		t.Errorf("Expected the variables and configuration to be read")
+	}
+}
+
+func TestDecodeJSONTool(t *testing.T) {
+	cases := map[string]struct {
+		input    string
+		expected string
+	}{
+		"terraform provider": {
+			input:    `{"format_version":"1.2","terraform_version":"1.5.0","resource_changes":[{"address":"a","provider_name":"registry.terraform.io/hashicorp/google","change":{"actions":["create"]}}]}`,
+			expected: ToolTerraform,
+		},
+		"opentofu provider": {
+			input:    `{"format_version":"1.2","terraform_version":"1.6.0","resource_changes":[{"address":"a","provider_name":"registry.opentofu.org/hashicorp/google","change":{"actions":["create"]}}]}`,
+			expected: ToolOpenTofu,
+		},
+		"only a version": {
+			input:    `{"format_version":"1.2","terraform_version":"1.5.0"}`,
+			expected: ToolTerraform,
+		},
+		"nothing identifies the tool": {
+			input:    `{"format_version":"1.2"}`,
+			expected: "",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := DecodeJSON(strings.NewReader(tc.input), func(ResourceChange) error { return nil })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if p.Tool != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, p.Tool)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONActions(t *testing.T) {
+	cases := map[string]struct {
+		actions  string
+		expected string
+	}{
+		"create": {
+			actions:  `["create"]`,
+			expected: "create",
+		},
+		"delete": {
+			actions:  `["delete"]`,
+			expected: "delete",
+		},
+		"update": {
+			actions:  `["update"]`,
+			expected: "update",
+		},
+		"delete before create": {
+			actions:  `["delete","create"]`,
+			expected: "replace",
+		},
+		"create before delete": {
+			actions:  `["create","delete"]`,
+			expected: "replace",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := `{"format_version":"1.2","resource_changes":[{"address":"a","change":{"actions":` + tc.actions + `}}]}`
+			var got string
+			_, err := DecodeJSON(strings.NewReader(input), func(rc ResourceChange) error {
+				got = newGoldenResourceChange(rc).Action
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	stop := errors.New("stop")
+
+	cases := map[string]struct {
+		input string
+		fn    func(ResourceChange) error
+		err   error
+	}{
+		"callback error stops reading": {
+			input: `{"format_version":"1.2","resource_changes":[{"address":"a","change":{"actions":["create"]}},{"address":"b","change":{"actions":["create"]}},`,
+			fn: func(ResourceChange) error {
+				return stop
+			},
+			err: stop,
+		},
+		"unsupported format version": {
+			input: `{"format_version":"2.0","resource_changes":[]}`,
+		},
+		"missing format version": {
+			input: `{"resource_changes":[]}`,
+		},
+		"truncated input": {
+			input: `{"format_version":"1.2","resource_changes":[{"address":"a","change":{"actions":["create"]}}`,
+		},
+		"not an object": {
+			input: `[]`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fn := tc.fn
+			if fn == nil {
+				fn = func(ResourceChange) error { return nil }
+			}
+
+			_, err := DecodeJSON(strings.NewReader(tc.input), fn)
+			if err == nil {
+				t.Fatalf("Expected an error but got none")
+			}
+			if tc.err != nil && err != tc.err {
+				t.Errorf("Expected error %v but got %v", tc.err, err)
+			}
+		})
+	}
+}
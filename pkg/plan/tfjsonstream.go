@@ -0,0 +1,223 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	streamActionCreate  = "create"
+	streamActionRead    = "read"
+	streamActionUpdate  = "update"
+	streamActionReplace = "replace"
+	streamActionDelete  = "delete"
+)
+
+// streamMessage is a single line of the machine readable output of "terraform plan -json"
+type streamMessage struct {
+	Level      string            `json:"@level"`
+	Message    string            `json:"@message"`
+	Type       string            `json:"type"`
+	Change     *streamChange     `json:"change,omitempty"`
+	Changes    *streamSummary    `json:"changes,omitempty"`
+	Diagnostic *streamDiagnostic `json:"diagnostic,omitempty"`
+
+	// Terraform and Tofu are the tool versions reported by the version message
+	// OpenTofu reports its version as "tofu"
+	Terraform string `json:"terraform,omitempty"`
+	Tofu      string `json:"tofu,omitempty"`
+}
+
+type streamChange struct {
+	Resource streamResource `json:"resource"`
+	Action   string         `json:"action"`
+}
+
+type streamResource struct {
+	Addr         string `json:"addr"`
+	Module       string `json:"module"`
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+}
+
+type streamSummary struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+type streamDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+}
+
+// jsonStreamChange is a planned change from the machine readable output, which has no attribute values
+type jsonStreamChange struct {
+	Change *streamChange
+}
+
+// NewPlanFromJSONStream reads the newline delimited JSON messages of "terraform plan -json"
+// The change summary message is required, and is compared against the planned changes to verify the output is complete
+func NewPlanFromJSONStream(in io.Reader) (*Plan, error) {
+	result := &Plan{}
+	var parsed Summary
+	forgotten := 0
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var msg streamMessage
+		if err := json.Unmarshal([]byte(text), &msg); err != nil {
+			return nil, fmt.Errorf("line %d is not a JSON message: %v", line, err)
+		}
+
+		switch msg.Type {
+		case "version":
+			if msg.Tofu != "" {
+				result.Tool = ToolOpenTofu
+				result.ToolVersion = msg.Tofu
+			} else {
+				result.Tool = ToolTerraform
+				result.ToolVersion = msg.Terraform
+			}
+		case "planned_change":
+			if msg.Change == nil {
+				return nil, fmt.Errorf("line %d: planned change is missing the change", line)
+			}
+			if msg.Change.Action == actionForget {
+				forgotten++
+				continue
+			}
+			result.ResourceChanges = append(result.ResourceChanges, newJSONStreamChange(msg.Change))
+			parsed = addStreamAction(parsed, msg.Change.Action)
+		case "resource_drift":
+			if msg.Change == nil {
+				return nil, fmt.Errorf("line %d: resource drift is missing the change", line)
+			}
+			result.Drift = append(result.Drift, newJSONStreamChange(msg.Change))
+		case "change_summary":
+			if msg.Changes == nil {
+				return nil, fmt.Errorf("line %d: change summary is missing the changes", line)
+			}
+			result.Summary = &Summary{
+				Add:    msg.Changes.Add,
+				Change: msg.Changes.Change,
+				Remove: msg.Changes.Remove,
+			}
+		case "diagnostic":
+			if msg.Diagnostic != nil && msg.Diagnostic.Severity == "error" {
+				return nil, fmt.Errorf("plan failed: %s", msg.Diagnostic.Summary)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if result.Summary == nil {
+		return nil, fmt.Errorf("plan output has no change summary, it may be incomplete")
+	}
+	if *result.Summary != parsed {
+		if forgotten > 0 {
+			return nil, fmt.Errorf("plan output reports %s but %s were parsed (forgotten resources are skipped: %d)", result.Summary, parsed, forgotten)
+		}
+		return nil, fmt.Errorf("plan output reports %s but %s were parsed", result.Summary, parsed)
+	}
+
+	return result, nil
+}
+
+// addStreamAction adds the action to the summary in the same way terraform counts changes
+func addStreamAction(s Summary, action string) Summary {
+	switch action {
+	case streamActionCreate:
+		s.Add++
+	case streamActionUpdate:
+		s.Change++
+	case streamActionDelete:
+		s.Remove++
+	case streamActionReplace:
+		s.Add++
+		s.Remove++
+	}
+
+	return s
+}
+
+func newJSONStreamChange(change *streamChange) ResourceChange {
+	return &jsonStreamChange{
+		Change: change,
+	}
+}
+
+func (j *jsonStreamChange) IsCreate() bool {
+	return j.Change.Action == streamActionCreate || j.Change.Action == streamActionReplace
+}
+
+func (j *jsonStreamChange) IsDelete() bool {
+	return j.Change.Action == streamActionDelete || j.Change.Action == streamActionReplace
+}
+
+func (j *jsonStreamChange) IsNoOp() bool {
+	switch j.Change.Action {
+	case streamActionCreate, streamActionRead, streamActionUpdate, streamActionReplace, streamActionDelete:
+		return false
+	}
+	return true
+}
+
+func (j *jsonStreamChange) IsUpdate() bool {
+	return j.Change.Action == streamActionUpdate
+}
+
+func (j *jsonStreamChange) IsRead() bool {
+	return j.Change.Action == streamActionRead
+}
+
+func (j *jsonStreamChange) GetBefore() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (j *jsonStreamChange) GetAfter() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (j *jsonStreamChange) GetBeforeChangedOnly() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (j *jsonStreamChange) GetAfterChangedOnly() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (j *jsonStreamChange) GetComputed() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (j *jsonStreamChange) GetName() string {
+	return j.Change.Resource.ResourceName
+}
+
+func (j *jsonStreamChange) GetType() string {
+	return j.Change.Resource.ResourceType
+}
+
+func (j *jsonStreamChange) GetAddress() string {
+	return j.Change.Resource.Addr
+}
+
+func (j *jsonStreamChange) GetMode() string {
+	return modeFromAddress(j.Change.Resource.Addr)
+}
@@ -0,0 +1,137 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPlanFromJSONStream(t *testing.T) {
+	f, err := os.Open("testdata/plan-stream.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := NewPlanFromJSONStream(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got []goldenResourceChange
+	for _, rc := range p.ResourceChanges {
+		g := newGoldenResourceChange(rc)
+		got = append(got, goldenResourceChange{
+			Address: g.Address,
+			Type:    g.Type,
+			Name:    g.Name,
+			Mode:    g.Mode,
+			Action:  g.Action,
+		})
+	}
+
+	expected := []goldenResourceChange{
+		{Address: "google_compute_instance.web", Type: "google_compute_instance", Name: "web", Mode: ManagedMode, Action: "create"},
+		{Address: "google_storage_bucket.logs", Type: "google_storage_bucket", Name: "logs", Mode: ManagedMode, Action: "update"},
+		{Address: "module.db.google_sql_database_instance.main", Type: "google_sql_database_instance", Name: "main", Mode: ManagedMode, Action: "replace"},
+		{Address: `google_pubsub_topic.old["a"]`, Type: "google_pubsub_topic", Name: "old", Mode: ManagedMode, Action: "delete"},
+		{Address: "data.google_compute_image.web", Type: "google_compute_image", Name: "web", Mode: DataMode, Action: "read"},
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Resource changes mismatch (-expected +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff(&Summary{Add: 2, Change: 1, Remove: 2}, p.Summary); diff != "" {
+		t.Errorf("Summary mismatch (-expected +got):\n%s", diff)
+	}
+
+	var drift []goldenResourceChange
+	for _, rc := range p.Drift {
+		g := newGoldenResourceChange(rc)
+		drift = append(drift, goldenResourceChange{
+			Address: g.Address,
+			Type:    g.Type,
+			Name:    g.Name,
+			Mode:    g.Mode,
+			Action:  g.Action,
+		})
+	}
+	expectedDrift := []goldenResourceChange{
+		{Address: "google_storage_bucket.logs", Type: "google_storage_bucket", Name: "logs", Mode: ManagedMode, Action: "update"},
+	}
+	if diff := cmp.Diff(expectedDrift, drift); diff != "" {
+		t.Errorf("Drift mismatch (-expected +got):\n%s", diff)
+	}
+}
+
+func TestNewPlanFromJSONStreamErrors(t *testing.T) {
+	cases := map[string]struct {
+		input         string
+		expectedError string
+	}{
+		"truncated output": {
+			input:         "testdata/plan-stream-truncated.jsonl",
+			expectedError: "no change summary",
+		},
+		"failed plan": {
+			input:         "testdata/plan-stream-error.jsonl",
+			expectedError: "plan failed: Invalid reference",
+		},
+		"not machine readable output": {
+			input:         "testdata/plan-output/terraform-0.12.txt",
+			expectedError: "line 1 is not a JSON message",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			_, err = NewPlanFromJSONStream(f)
+			if err == nil {
+				t.Fatalf("Expected an error but got none")
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error to contain %q but got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestNewPlanFromJSONStreamSummaryMismatch(t *testing.T) {
+	create := `{"type":"planned_change","change":{"resource":{"addr":"google_compute_instance.web","resource_type":"google_compute_instance","resource_name":"web"},"action":"create"}}`
+	forget := `{"type":"planned_change","change":{"resource":{"addr":"google_compute_instance.old","resource_type":"google_compute_instance","resource_name":"old"},"action":"forget"}}`
+	summary := `{"type":"change_summary","changes":{"add":2,"change":0,"remove":0,"operation":"plan"}}`
+
+	cases := map[string]struct {
+		input         []string
+		expectedError string
+	}{
+		"missing change": {
+			input:         []string{create, summary},
+			expectedError: "reports 2 to add, 0 to change, 0 to destroy but 1 to add, 0 to change, 0 to destroy were parsed",
+		},
+		"forgotten resources": {
+			input:         []string{create, forget, summary},
+			expectedError: "reports 2 to add, 0 to change, 0 to destroy but 1 to add, 0 to change, 0 to destroy were parsed (forgotten resources are skipped: 1)",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewPlanFromJSONStream(strings.NewReader(strings.Join(tc.input, "\n")))
+			if err == nil {
+				t.Fatalf("Expected an error but got none")
+			}
+			if !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("Expected error to contain %q but got %q", tc.expectedError, err.Error())
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/drlau/tfplanparse"
@@ -8,23 +9,74 @@ import (
 
 type tfPlanChange struct {
 	ResourceChange *tfplanparse.ResourceChange
+
+	// tfplanparse walks every attribute each time values are requested,
+	// and the comparers request them several times per resource
+	before, after, beforeChangedOnly, afterChangedOnly, computed memoValues
 }
 
 func NewResourcePlanFromPlanOutput(in io.Reader) ([]ResourceChange, error) {
-	var result []ResourceChange
+	p, err := NewPlanFromPlanOutput(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ResourceChanges, nil
+}
 
-	parsed, err := tfplanparse.Parse(in)
+// NewPlanFromPlanOutput parses the human readable output of "terraform plan"
+// The "Plan:" summary line is required, and is compared against the parsed resources to verify the output is complete
+func NewPlanFromPlanOutput(in io.Reader) (*Plan, error) {
+	normalized, info, err := normalizePlanOutput(in)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 
+	parsed, err := tfplanparse.Parse(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Plan{
+		Summary: info.Summary,
+		Tool:    info.Tool,
+	}
+	var counted Summary
 	for _, rc := range parsed {
-		result = append(result, newTFPlanChange(rc))
+		for _, a := range rc.AttributeChanges {
+			splitHeredocLines(a)
+		}
+		result.ResourceChanges = append(result.ResourceChanges, newTFPlanChange(rc))
+		counted = addPlanOutputChange(counted, rc.UpdateType)
+	}
+
+	if info.Summary == nil {
+		return nil, fmt.Errorf("plan output has no summary, it may be incomplete")
+	}
+	if *info.Summary != counted {
+		return nil, fmt.Errorf("plan output reports %s but %s were parsed", info.Summary, counted)
 	}
 
 	return result, nil
 }
 
+// addPlanOutputChange adds the change to the summary in the same way terraform counts changes
+func addPlanOutputChange(s Summary, updateType tfplanparse.UpdateType) Summary {
+	switch updateType {
+	case tfplanparse.NewResource:
+		s.Add++
+	case tfplanparse.UpdateInPlaceResource:
+		s.Change++
+	case tfplanparse.DestroyResource:
+		s.Remove++
+	case tfplanparse.ForceReplaceResource:
+		s.Add++
+		s.Remove++
+	}
+
+	return s
+}
+
 func newTFPlanChange(rc *tfplanparse.ResourceChange) ResourceChange {
 	return &tfPlanChange{
 		ResourceChange: rc,
@@ -32,11 +84,11 @@ func newTFPlanChange(rc *tfplanparse.ResourceChange) ResourceChange {
 }
 
 func (t *tfPlanChange) IsCreate() bool {
-	return t.ResourceChange.UpdateType == tfplanparse.NewResource
+	return t.ResourceChange.UpdateType == tfplanparse.NewResource || t.ResourceChange.UpdateType == tfplanparse.ForceReplaceResource
 }
 
 func (t *tfPlanChange) IsDelete() bool {
-	return t.ResourceChange.UpdateType == tfplanparse.DestroyResource
+	return t.ResourceChange.UpdateType == tfplanparse.DestroyResource || t.ResourceChange.UpdateType == tfplanparse.ForceReplaceResource
 }
 
 func (t *tfPlanChange) IsNoOp() bool {
@@ -44,27 +96,41 @@ func (t *tfPlanChange) IsNoOp() bool {
 }
 
 func (t *tfPlanChange) IsUpdate() bool {
-	return t.ResourceChange.UpdateType == tfplanparse.UpdateInPlaceResource || t.ResourceChange.UpdateType == tfplanparse.ForceReplaceResource
+	return t.ResourceChange.UpdateType == tfplanparse.UpdateInPlaceResource
+}
+
+func (t *tfPlanChange) IsRead() bool {
+	return t.ResourceChange.UpdateType == tfplanparse.ReadResource
 }
 
 func (t *tfPlanChange) GetBefore() map[string]interface{} {
-	return t.ResourceChange.GetBeforeResource(tfplanparse.IgnoreSensitive)
+	return t.before.get(func() map[string]interface{} {
+		return t.ResourceChange.GetBeforeResource(tfplanparse.IgnoreSensitive)
+	})
 }
 
 func (t *tfPlanChange) GetAfter() map[string]interface{} {
-	return t.ResourceChange.GetAfterResource(tfplanparse.IgnoreSensitive)
+	return t.after.get(func() map[string]interface{} {
+		return t.ResourceChange.GetAfterResource(tfplanparse.IgnoreSensitive)
+	})
 }
 
 func (t *tfPlanChange) GetBeforeChangedOnly() map[string]interface{} {
-	return t.ResourceChange.GetBeforeResource(tfplanparse.IgnoreSensitive, tfplanparse.IgnoreNoOp)
+	return t.beforeChangedOnly.get(func() map[string]interface{} {
+		return t.ResourceChange.GetBeforeResource(tfplanparse.IgnoreSensitive, tfplanparse.IgnoreNoOp)
+	})
 }
 
 func (t *tfPlanChange) GetAfterChangedOnly() map[string]interface{} {
-	return t.ResourceChange.GetAfterResource(tfplanparse.IgnoreSensitive, tfplanparse.IgnoreNoOp)
+	return t.afterChangedOnly.get(func() map[string]interface{} {
+		return t.ResourceChange.GetAfterResource(tfplanparse.IgnoreSensitive, tfplanparse.IgnoreNoOp)
+	})
 }
 
 func (t *tfPlanChange) GetComputed() map[string]interface{} {
-	return t.ResourceChange.GetAfterResource(tfplanparse.ComputedOnly)
+	return t.computed.get(func() map[string]interface{} {
+		return t.ResourceChange.GetAfterResource(tfplanparse.ComputedOnly)
+	})
 }
 
 func (t *tfPlanChange) GetName() string {
@@ -78,3 +144,8 @@ func (t *tfPlanChange) GetType() string {
 func (t *tfPlanChange) GetAddress() string {
 	return t.ResourceChange.Address
 }
+
+// GetMode determines the mode from the address, as tfplanparse removes "data" from the type
+func (t *tfPlanChange) GetMode() string {
+	return modeFromAddress(t.ResourceChange.Address)
+}
@@ -0,0 +1,117 @@
+package plan
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+type goldenResourceChange struct {
+	Address  string                 `json:"address"`
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Mode     string                 `json:"mode"`
+	Action   string                 `json:"action"`
+	Before   map[string]interface{} `json:"before"`
+	After    map[string]interface{} `json:"after"`
+	Computed map[string]interface{} `json:"computed"`
+}
+
+func newGoldenResourceChange(rc ResourceChange) goldenResourceChange {
+	var action string
+	switch {
+	case rc.IsCreate() && rc.IsDelete():
+		action = "replace"
+	case rc.IsCreate():
+		action = "create"
+	case rc.IsDelete():
+		action = "delete"
+	case rc.IsUpdate():
+		action = "update"
+	case rc.IsRead():
+		action = "read"
+	case rc.IsNoOp():
+		action = "no-op"
+	}
+
+	return goldenResourceChange{
+		Address:  rc.GetAddress(),
+		Type:     rc.GetType(),
+		Name:     rc.GetName(),
+		Mode:     rc.GetMode(),
+		Action:   action,
+		Before:   rc.GetBefore(),
+		After:    rc.GetAfter(),
+		Computed: rc.GetComputed(),
+	}
+}
+
+// TestNewResourcePlanFromPlanOutputGolden parses the plan output of each terraform version in testdata/plan-output
+// and compares the result to the matching golden file
+// Run "go test ./pkg/plan -update" to regenerate the golden files
+func TestNewResourcePlanFromPlanOutputGolden(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/plan-output/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".txt")
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			rc, err := NewResourcePlanFromPlanOutput(f)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := []goldenResourceChange{}
+			for _, r := range rc {
+				got = append(got, newGoldenResourceChange(r))
+			}
+			// round trip through JSON so values compare the same way as the golden file
+			data, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(fixture, ".txt") + ".golden.json"
+			if *update {
+				if err := ioutil.WriteFile(golden, append(data, '\n'), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var expectedValue, gotValue interface{}
+			if err := json.Unmarshal(expected, &expectedValue); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &gotValue); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expectedValue, gotValue); diff != "" {
+				t.Errorf("Result mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
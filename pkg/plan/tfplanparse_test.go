@@ -0,0 +1,159 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/drlau/tfplanparse"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTFPlanChangeGetMode(t *testing.T) {
+	cases := map[string]struct {
+		address  string
+		expected string
+	}{
+		"resource": {
+			address:  "google_compute_instance.web",
+			expected: ManagedMode,
+		},
+		"data source": {
+			address:  "data.google_client_config.current",
+			expected: DataMode,
+		},
+		"data source in module": {
+			address:  "module.network.data.google_compute_zones.available",
+			expected: DataMode,
+		},
+		"resource in module named data": {
+			address:  "module.data.google_compute_instance.web",
+			expected: ManagedMode,
+		},
+		"data source with index": {
+			address:  `data.google_compute_image.image["debian.9"]`,
+			expected: DataMode,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rc := newTFPlanChange(&tfplanparse.ResourceChange{
+				Address: tc.address,
+			})
+			if got := rc.GetMode(); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromPlanOutputSummary(t *testing.T) {
+	const changes = `Terraform will perform the following actions:
+
+  # google_compute_instance.web will be created
+  + resource "google_compute_instance" "web" {
+      + name = "web"
+    }
+
+  # google_storage_bucket.logs will be destroyed
+  - resource "google_storage_bucket" "logs" {
+      - name = "logs" -> null
+    }
+
+`
+
+	cases := map[string]struct {
+		input    string
+		expected *Summary
+		err      bool
+	}{
+		"matching summary": {
+			input:    changes + "Plan: 1 to add, 0 to change, 1 to destroy.\n",
+			expected: &Summary{Add: 1, Remove: 1},
+		},
+		"colored output": {
+			input:    "\x1b[1m" + changes + "\x1b[0m\x1b[1mPlan:\x1b[0m 1 to add, 0 to change, 1 to destroy.\n",
+			expected: &Summary{Add: 1, Remove: 1},
+		},
+		"no changes": {
+			input:    "No changes. Your infrastructure matches the configuration.\n",
+			expected: &Summary{},
+		},
+		"truncated output": {
+			input: changes[:len(changes)/2],
+			err:   true,
+		},
+		"missing summary": {
+			input: changes,
+			err:   true,
+		},
+		"empty output": {
+			input: "",
+			err:   true,
+		},
+		"summary mismatch": {
+			input: changes + "Plan: 2 to add, 0 to change, 1 to destroy.\n",
+			err:   true,
+		},
+		"unrecognized changes": {
+			input: "Terraform will perform the following actions:\n\n  unrecognized\n\nPlan: 1 to add, 0 to change, 0 to destroy.\n",
+			err:   true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewPlanFromPlanOutput(strings.NewReader(tc.input))
+			if tc.err {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, p.Summary); diff != "" {
+				t.Errorf("Summary mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPlanFromPlanOutputHeredoc(t *testing.T) {
+	const input = `Terraform will perform the following actions:
+
+  # google_compute_instance.web will be updated in-place
+  ~ resource "google_compute_instance" "web" {
+      ~ metadata = {
+          ~ "user-data" = <<-EOT
+                packages:
+                - curl
+              - - vim
+              + - git
+            EOT
+        }
+        name     = "web"
+    }
+
+Plan: 0 to add, 1 to change, 0 to destroy.
+`
+
+	p, err := NewPlanFromPlanOutput(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(p.ResourceChanges) != 1 {
+		t.Fatalf("Expected 1 resource change but got %d", len(p.ResourceChanges))
+	}
+
+	rc := p.ResourceChanges[0]
+	expectedBefore := map[string]interface{}{"user-data": "packages:\n- curl\n- vim"}
+	expectedAfter := map[string]interface{}{"user-data": "packages:\n- curl\n- git"}
+	if diff := cmp.Diff(expectedBefore, rc.GetBefore()["metadata"]); diff != "" {
+		t.Errorf("Before mismatch (-expected +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(expectedAfter, rc.GetAfter()["metadata"]); diff != "" {
+		t.Errorf("After mismatch (-expected +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,135 @@
+package plan
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+// stateResource is a resource in the current state
+// It is treated as an existing resource with no planned changes
+type stateResource struct {
+	Resource *tfjson.StateResource
+}
+
+// NewPlanFromStateJSON reads the output of "terraform show -json" for a state file
+// Every managed resource in the state is returned as an existing resource
+func NewPlanFromStateJSON(in io.Reader) (*Plan, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	// as with plans, the version is checked here to accept the newer versions tfjson rejects
+	type rawState tfjson.State
+	parsed := &tfjson.State{}
+	if err := json.Unmarshal(data, (*rawState)(parsed)); err != nil {
+		return nil, err
+	}
+	if err := checkFormatVersion(parsed.FormatVersion); err != nil {
+		return nil, err
+	}
+
+	result := &Plan{
+		ToolVersion: parsed.TerraformVersion,
+	}
+	if parsed.Values != nil {
+		result.ResourceChanges = appendStateResources(result.ResourceChanges, parsed.Values.RootModule)
+	}
+
+	var providerNames []string
+	for _, rc := range result.ResourceChanges {
+		if r, ok := rc.(*stateResource); ok {
+			providerNames = append(providerNames, r.Resource.ProviderName)
+		}
+	}
+	result.Tool = toolFromProviderNames(providerNames)
+
+	return result, nil
+}
+
+// appendStateResources recursively appends the managed resources of the module and its children
+func appendStateResources(result []ResourceChange, module *tfjson.StateModule) []ResourceChange {
+	if module == nil {
+		return result
+	}
+
+	for _, r := range module.Resources {
+		if r == nil || r.Mode != tfjson.ManagedResourceMode {
+			continue
+		}
+		result = append(result, newStateResource(r))
+	}
+	for _, m := range module.ChildModules {
+		result = appendStateResources(result, m)
+	}
+
+	return result
+}
+
+func newStateResource(r *tfjson.StateResource) ResourceChange {
+	return &stateResource{
+		Resource: r,
+	}
+}
+
+func (s *stateResource) IsCreate() bool {
+	return false
+}
+
+func (s *stateResource) IsDelete() bool {
+	return false
+}
+
+func (s *stateResource) IsNoOp() bool {
+	return true
+}
+
+func (s *stateResource) IsUpdate() bool {
+	return false
+}
+
+func (s *stateResource) IsRead() bool {
+	return false
+}
+
+func (s *stateResource) GetBefore() map[string]interface{} {
+	if s.Resource.AttributeValues != nil {
+		return s.Resource.AttributeValues
+	}
+	return map[string]interface{}{}
+}
+
+func (s *stateResource) GetAfter() map[string]interface{} {
+	return s.GetBefore()
+}
+
+func (s *stateResource) GetBeforeChangedOnly() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (s *stateResource) GetAfterChangedOnly() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (s *stateResource) GetComputed() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (s *stateResource) GetName() string {
+	return s.Resource.Name
+}
+
+func (s *stateResource) GetType() string {
+	return s.Resource.Type
+}
+
+func (s *stateResource) GetAddress() string {
+	return s.Resource.Address
+}
+
+func (s *stateResource) GetMode() string {
+	return string(s.Resource.Mode)
+}
@@ -0,0 +1,45 @@
+package plan
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewPlanFromStateJSON(t *testing.T) {
+	f, err := os.Open("testdata/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	p, err := NewPlanFromStateJSON(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var addresses []string
+	for _, rc := range p.ResourceChanges {
+		if !rc.IsNoOp() || rc.IsCreate() || rc.IsDelete() || rc.IsUpdate() {
+			t.Errorf("Expected %s to be an existing resource", rc.GetAddress())
+		}
+		addresses = append(addresses, rc.GetAddress())
+	}
+
+	expected := []string{
+		"google_compute_instance.web",
+		"module.network.google_compute_network.vpc",
+	}
+	if diff := cmp.Diff(expected, addresses); diff != "" {
+		t.Errorf("Addresses mismatch (-expected +got):\n%s", diff)
+	}
+
+	expectedValues := map[string]interface{}{
+		"auto_create_subnetworks": false,
+		"name":                    "vpc",
+	}
+	if diff := cmp.Diff(expectedValues, p.ResourceChanges[1].GetAfter()); diff != "" {
+		t.Errorf("Values mismatch (-expected +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,54 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// ToolTerraform is a plan made with terraform
+	ToolTerraform = "terraform"
+	// ToolOpenTofu is a plan made with OpenTofu
+	ToolOpenTofu = "opentofu"
+	// ToolPulumi is a preview made with pulumi
+	ToolPulumi = "pulumi"
+	// ToolCloudFormation is a change set made with AWS CloudFormation
+	ToolCloudFormation = "cloudformation"
+)
+
+// actionForget is the action of a resource that is removed from the state without being destroyed, which is skipped
+const actionForget = "forget"
+
+// openTofuRegistry is the registry OpenTofu installs providers from by default
+const openTofuRegistry = "registry.opentofu.org/"
+
+// supportedFormatVersions are the major versions of the JSON plan and state formats that can be read
+var supportedFormatVersions = []string{"0", "1"}
+
+// checkFormatVersion returns an error if the JSON format version is missing or has an unsupported major version
+func checkFormatVersion(version string) error {
+	if version == "" {
+		return fmt.Errorf("unexpected input, format version is missing")
+	}
+
+	major := strings.SplitN(version, ".", 2)[0]
+	for _, v := range supportedFormatVersions {
+		if major == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported format version %q", version)
+}
+
+// toolFromProviderNames determines the tool from the registry of the full provider names, such as "registry.opentofu.org/hashicorp/aws"
+func toolFromProviderNames(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, openTofuRegistry) {
+			return ToolOpenTofu
+		}
+	}
+	return ToolTerraform
+}
@@ -0,0 +1,124 @@
+package plan
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCheckFormatVersion(t *testing.T) {
+	cases := map[string]struct {
+		version string
+		err     bool
+	}{
+		"terraform 0.12": {
+			version: "0.1",
+		},
+		"terraform 1.x": {
+			version: "1.2",
+		},
+		"missing": {
+			version: "",
+			err:     true,
+		},
+		"unsupported major version": {
+			version: "2.0",
+			err:     true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := checkFormatVersion(tc.version)
+			if tc.err && err == nil {
+				t.Errorf("Expected an error but got none")
+			}
+			if !tc.err && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDetectTool(t *testing.T) {
+	cases := map[string]struct {
+		file            string
+		expectedTool    string
+		expectedVersion string
+		expectedChanges int
+	}{
+		"terraform plan output": {
+			file:            "testdata/plan-output/terraform-1.5.txt",
+			expectedTool:    ToolTerraform,
+			expectedChanges: 2,
+		},
+		"terraform 0.12 plan output": {
+			file:            "testdata/plan-output/terraform-0.12.txt",
+			expectedTool:    ToolTerraform,
+			expectedChanges: 4,
+		},
+		"OpenTofu plan output": {
+			file:            "testdata/plan-output/opentofu-1.6.txt",
+			expectedTool:    ToolOpenTofu,
+			expectedChanges: 3,
+		},
+		"terraform JSON plan": {
+			file:            "testdata/plan.json",
+			expectedTool:    ToolTerraform,
+			expectedVersion: "0.12.29",
+			expectedChanges: 3,
+		},
+		"OpenTofu JSON plan": {
+			file:            "testdata/plan-opentofu.json",
+			expectedTool:    ToolOpenTofu,
+			expectedVersion: "1.7.2",
+			expectedChanges: 2,
+		},
+		"terraform JSON stream": {
+			file:            "testdata/plan-stream.jsonl",
+			expectedTool:    ToolTerraform,
+			expectedVersion: "1.5.7",
+			expectedChanges: 5,
+		},
+		"OpenTofu JSON stream": {
+			file:            "testdata/plan-stream-opentofu.jsonl",
+			expectedTool:    ToolOpenTofu,
+			expectedVersion: "1.7.2",
+			expectedChanges: 2,
+		},
+		"terraform JSON state": {
+			file:            "testdata/state.json",
+			expectedTool:    ToolTerraform,
+			expectedVersion: "0.12.29",
+			expectedChanges: 2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f, err := os.Open(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			format, data, err := DetectFormat(f)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			p, err := NewPlanFromFormat(format, data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if p.Tool != tc.expectedTool {
+				t.Errorf("Expected tool %q but got %q", tc.expectedTool, p.Tool)
+			}
+			if p.ToolVersion != tc.expectedVersion {
+				t.Errorf("Expected tool version %q but got %q", tc.expectedVersion, p.ToolVersion)
+			}
+			if len(p.ResourceChanges) != tc.expectedChanges {
+				t.Errorf("Expected %d resource changes but got %d", tc.expectedChanges, len(p.ResourceChanges))
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ type FakeResource struct {
 	CompareResultReturns *resource.CompareResult
 	CompareReturns       bool
 	DiffReturns          string
+	FailuresReturns      []string
 }
 
 func (r *FakeResource) CompareResult(values map[string]interface{}) *resource.CompareResult {
@@ -21,3 +22,7 @@ func (r *FakeResource) Compare(rv resource.ResourceValues, opts resource.Compare
 func (r *FakeResource) Diff(rv resource.ResourceValues, opts resource.CompareOptions) string {
 	return r.DiffReturns
 }
+
+func (r *FakeResource) Failures(rv resource.ResourceValues, opts resource.CompareOptions) []string {
+	return r.FailuresReturns
+}
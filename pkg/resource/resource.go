@@ -3,6 +3,8 @@ package resource
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/drlau/akashi/pkg/ruleset"
@@ -18,6 +20,7 @@ type Resource interface {
 	CompareResult(map[string]interface{}) *CompareResult
 	Compare(ResourceValues, CompareOptions) bool
 	Diff(ResourceValues, CompareOptions) string
+	Failures(ResourceValues, CompareOptions) []string
 }
 
 type resource struct {
@@ -28,6 +31,9 @@ type resource struct {
 
 	Enforced map[string]ruleset.EnforceChange
 	Ignored  map[string]interface{}
+
+	// patterns are the compiled match patterns of Enforced, or nil for an invalid pattern
+	patterns map[string]*regexp.Regexp
 }
 
 // TODO: consider moving this to functions
@@ -51,7 +57,22 @@ func NewResourceFromConfig(resourceIdentifier ruleset.ResourceIdentifier, resour
 		Type:     resourceIdentifier.Type,
 		Enforced: resourceRules.Enforced,
 		Ignored:  ignored,
+		patterns: compilePatterns(resourceRules.Enforced),
+	}
+}
+
+// compilePatterns compiles the match patterns of the enforced arguments
+// Invalid patterns are reported when the ruleset is loaded, so they are left as nil and never match
+func compilePatterns(enforced map[string]ruleset.EnforceChange) map[string]*regexp.Regexp {
+	result := make(map[string]*regexp.Regexp)
+	for k, e := range enforced {
+		if e.Match == "" {
+			continue
+		}
+		re, _ := regexp.Compile(e.Match)
+		result[k] = re
 	}
+	return result
 }
 
 func (r *resource) CompareResult(values map[string]interface{}) *CompareResult {
@@ -101,6 +122,17 @@ func (r *resource) CompareResult(values map[string]interface{}) *CompareResult {
 						MatchAny: true,
 					}
 				}
+			case enforced.Match != "":
+				// Verify the value matches the pattern
+				if !match(r.patterns[k], v) {
+					failedArgs[k] = FailedArg{
+						Expected: enforced.Match,
+						Actual:   v,
+						Match:    true,
+					}
+				} else {
+					enforcedArgs[k] = enforced
+				}
 			default:
 				// TODO: Tests that key exists and that's it - intended?
 			}
@@ -124,13 +156,7 @@ func (r *resource) Compare(rv ResourceValues, opts CompareOptions) bool {
 	if opts.AutoFail {
 		return false
 	}
-	values := rv.Values
-	if opts.IgnoreNoOp && rv.ChangedValues != nil {
-		values = rv.ChangedValues
-	} else if !opts.IgnoreComputed {
-		values = rv.GetCombined()
-	}
-	cmp := r.CompareResult(values)
+	cmp := r.CompareResult(compareValues(rv, opts))
 
 	if opts.EnforceAll && len(cmp.MissingEnforced) > 0 {
 		return false
@@ -150,13 +176,7 @@ func (r *resource) Diff(rv ResourceValues, opts CompareOptions) string {
 		return utils.Red("AutoFail set to true")
 	}
 	var buf strings.Builder
-	values := rv.Values
-	if opts.IgnoreNoOp && rv.ChangedValues != nil {
-		values = rv.ChangedValues
-	} else if !opts.IgnoreComputed {
-		values = rv.GetCombined()
-	}
-	cmp := r.CompareResult(values)
+	cmp := r.CompareResult(compareValues(rv, opts))
 
 	if opts.EnforceAll && len(cmp.MissingEnforced) > 0 {
 		buf.WriteString(utils.Red("Missing enforced arguments:\n"))
@@ -194,6 +214,44 @@ func (r *resource) Diff(rv ResourceValues, opts CompareOptions) string {
 	return buf.String()
 }
 
+// Failures returns the arguments that fail the comparison, in the same way as Compare, sorted by name
+// The result is empty if the comparison fails without a failing argument, such as with autoFail
+func (r *resource) Failures(rv ResourceValues, opts CompareOptions) []string {
+	if opts.AutoFail {
+		return nil
+	}
+	cmp := r.CompareResult(compareValues(rv, opts))
+
+	failed := make(map[string]interface{})
+	if opts.EnforceAll {
+		failed = union(failed, cmp.MissingEnforced)
+	}
+	if !opts.IgnoreExtraArgs {
+		failed = union(failed, cmp.Extra)
+	}
+	if opts.RequireAll {
+		failed = union(union(failed, cmp.MissingEnforced), cmp.MissingIgnored)
+	}
+	failed = union(failed, cmp.Failed)
+
+	result := make([]string, 0, len(failed))
+	for k := range failed {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// compareValues returns the values to compare with the options
+func compareValues(rv ResourceValues, opts CompareOptions) map[string]interface{} {
+	if opts.IgnoreNoOp && rv.ChangedValues != nil {
+		return rv.ChangedValues
+	} else if !opts.IgnoreComputed {
+		return rv.GetCombined()
+	}
+	return rv.Values
+}
+
 func equal(expected, value interface{}) bool {
 	// YAML parses "key: {}" as a map[interface{}]interface{} which is different from map[string]interface{}
 	if mapExpected, ok := expected.(map[interface{}]interface{}); ok {
@@ -203,6 +261,16 @@ func equal(expected, value interface{}) bool {
 	return reflect.DeepEqual(expected, value)
 }
 
+// match returns true if the string representation of value matches the pattern
+// A nil pattern never matches
+func match(pattern *regexp.Regexp, value interface{}) bool {
+	if pattern == nil {
+		return false
+	}
+
+	return pattern.MatchString(fmt.Sprintf("%v", value))
+}
+
 // setDifference returns elements in A but not in B
 // only checks for key equality - ignores values
 func setDifference(a, b map[string]interface{}) map[string]interface{} {
@@ -216,6 +284,20 @@ func setDifference(a, b map[string]interface{}) map[string]interface{} {
 	return result
 }
 
+// union returns elements in either A or B
+// only checks for key equality - values of B take priority
+func union(a, b map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range a {
+		result[k] = v
+	}
+	for k, v := range b {
+		result[k] = v
+	}
+
+	return result
+}
+
 // enforcedSetDifference returns elements in A but not in B
 // only checks for key equality - ignores values
 func enforcedSetDifference(a map[string]ruleset.EnforceChange, b map[string]interface{}) map[string]interface{} {
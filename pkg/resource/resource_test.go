@@ -93,6 +93,56 @@ func TestResourceCompareResult(t *testing.T) {
 				MissingIgnored:  map[string]interface{}{},
 			},
 		},
+		"enforced pattern matches": {
+			resource: NewResourceFromConfig(ruleset.ResourceIdentifier{}, ruleset.ResourceRules{
+				Enforced: map[string]ruleset.EnforceChange{
+					"key": ruleset.EnforceChange{
+						Match: "^us-",
+					},
+				},
+			}),
+			values: map[string]interface{}{
+				"key": "us-central1",
+			},
+			expected: &CompareResult{
+				Enforced: map[string]interface{}{
+					"key": ruleset.EnforceChange{
+						Match: "^us-",
+					},
+				},
+				Failed:          map[string]interface{}{},
+				Ignored:         map[string]interface{}{},
+				Extra:           map[string]interface{}{},
+				MissingEnforced: map[string]interface{}{},
+				MissingIgnored:  map[string]interface{}{},
+			},
+		},
+		"enforced pattern does not match": {
+			resource: NewResourceFromConfig(ruleset.ResourceIdentifier{}, ruleset.ResourceRules{
+				Enforced: map[string]ruleset.EnforceChange{
+					"key": ruleset.EnforceChange{
+						Match: "^us-",
+					},
+				},
+			}),
+			values: map[string]interface{}{
+				"key": "europe-west1",
+			},
+			expected: &CompareResult{
+				Enforced: map[string]interface{}{},
+				Failed: map[string]interface{}{
+					"key": FailedArg{
+						Expected: "^us-",
+						Actual:   "europe-west1",
+						Match:    true,
+					},
+				},
+				Ignored:         map[string]interface{}{},
+				Extra:           map[string]interface{}{},
+				MissingEnforced: map[string]interface{}{},
+				MissingIgnored:  map[string]interface{}{},
+			},
+		},
 		"extra value": {
 			resource: &resource{
 				Enforced: map[string]ruleset.EnforceChange{
@@ -487,6 +537,106 @@ func TestResourceCompare(t *testing.T) {
 	}
 }
 
+func TestResourceFailures(t *testing.T) {
+	cases := map[string]struct {
+		resource Resource
+		opts     CompareOptions
+		values   ResourceValues
+		expected []string
+	}{
+		"passing resource": {
+			resource: &resource{
+				Enforced: map[string]ruleset.EnforceChange{
+					"key": ruleset.EnforceChange{
+						Value: "value",
+					},
+				},
+			},
+			values: ResourceValues{
+				Values: map[string]interface{}{
+					"key": "value",
+				},
+			},
+			expected: []string{},
+		},
+		"failed and extra arguments": {
+			resource: &resource{
+				Enforced: map[string]ruleset.EnforceChange{
+					"key": ruleset.EnforceChange{
+						Value: "value2",
+					},
+				},
+			},
+			values: ResourceValues{
+				Values: map[string]interface{}{
+					"key":   "value",
+					"extra": "value",
+				},
+			},
+			expected: []string{"extra", "key"},
+		},
+		"extra arguments are ignored": {
+			resource: &resource{
+				Enforced: map[string]ruleset.EnforceChange{
+					"key": ruleset.EnforceChange{
+						Value: "value2",
+					},
+				},
+			},
+			opts: CompareOptions{
+				IgnoreExtraArgs: true,
+			},
+			values: ResourceValues{
+				Values: map[string]interface{}{
+					"key":   "value",
+					"extra": "value",
+				},
+			},
+			expected: []string{"key"},
+		},
+		"missing enforced arguments with enforceAll": {
+			resource: &resource{
+				Enforced: map[string]ruleset.EnforceChange{
+					"key": ruleset.EnforceChange{
+						Value: "value",
+					},
+					"missing": ruleset.EnforceChange{
+						Value: "value",
+					},
+				},
+			},
+			opts: CompareOptions{
+				EnforceAll: true,
+			},
+			values: ResourceValues{
+				Values: map[string]interface{}{
+					"key": "value",
+				},
+			},
+			expected: []string{"missing"},
+		},
+		"autofail has no failing arguments": {
+			resource: &resource{},
+			opts: CompareOptions{
+				AutoFail: true,
+			},
+			values: ResourceValues{
+				Values: map[string]interface{}{
+					"key": "value",
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.expected, tc.resource.Failures(tc.values, tc.opts)); diff != "" {
+				t.Errorf("Result mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 // TODO: should verify order of expected strings
 func TestResourceDiff(t *testing.T) {
 	cases := map[string]struct {
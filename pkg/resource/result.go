@@ -24,4 +24,5 @@ type FailedArg struct {
 	Expected interface{}
 	Actual   interface{}
 	MatchAny bool
+	Match    bool
 }
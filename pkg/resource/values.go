@@ -7,13 +7,13 @@ type ResourceValues struct {
 	Computed      map[string]interface{}
 }
 
-// TODO: fix merging maps
+// GetCombined returns a new map of the values and computed values
+// The values are not modified, as plans may return the same map for every call
 func (rv ResourceValues) GetCombined() map[string]interface{} {
-	combined := rv.Values
-	if combined == nil {
-		combined = make(map[string]interface{})
+	combined := make(map[string]interface{}, len(rv.Values)+len(rv.Computed))
+	for k, v := range rv.Values {
+		combined[k] = v
 	}
-
 	for k, v := range rv.Computed {
 		combined[k] = v
 	}
@@ -59,3 +59,22 @@ func TestResourceValuesGetCombined(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceValuesGetCombinedDoesNotModifyValues(t *testing.T) {
+	rv := ResourceValues{
+		Values: map[string]interface{}{
+			"value1": "hello",
+		},
+		Computed: map[string]interface{}{
+			"computed": true,
+		},
+	}
+
+	rv.GetCombined()
+	expected := map[string]interface{}{
+		"value1": "hello",
+	}
+	if diff := cmp.Diff(rv.Values, expected); diff != "" {
+		t.Errorf("(-got, +expected)\n%s", diff)
+	}
+}
@@ -0,0 +1,322 @@
+package ruleset
+
+import (
+	"fmt"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// LintError is a finding for a rule that does not work as written
+	LintError = "error"
+	// LintWarning is a finding for a rule that works, but likely not as intended
+	LintWarning = "warning"
+)
+
+// Checks run by Lint
+const (
+	checkInvalid     = "invalid-ruleset"
+	checkDuplicate   = "duplicate-rule"
+	checkUnreachable = "unreachable-rule"
+	checkShadowed    = "shadowed-rule"
+	checkContradicts = "contradictory-rule"
+	checkUnsatisfied = "unsatisfiable-rule"
+	checkIneffective = "ineffective-option"
+)
+
+// LintFinding is a likely mistake in a ruleset found by Lint
+type LintFinding struct {
+	// Path is the path of the ruleset
+	Path string `json:"path"`
+
+	// Line is the line of the finding, or 0 if it is not known
+	Line int `json:"line,omitempty"`
+
+	// Level is either LintError or LintWarning
+	Level   string `json:"level"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (f LintFinding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s (%s)", f.Path, f.Level, f.Message, f.Check)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", f.Path, f.Line, f.Level, f.Message, f.Check)
+}
+
+// lintRule is a resource rule of any section, with the options it is compared with
+type lintRule struct {
+	path []interface{}
+	id   ResourceIdentifier
+	opts CompareOptions
+
+	// rules are the enforced and ignored arguments of the rule, keyed by their path within the rule
+	rules []lintRules
+}
+
+type lintRules struct {
+	path  []interface{}
+	rules ResourceRules
+}
+
+// linter lints a single ruleset file
+type linter struct {
+	path     string
+	root     yamlNode
+	findings []LintFinding
+}
+
+// Lint reads the rulesets at paths in the same way as Load, and reports rules that are likely mistakes
+// A ruleset that cannot be loaded is reported as a finding
+func Lint(paths ...string) ([]LintFinding, error) {
+	return LintWithOptions(Options{}, paths...)
+}
+
+// LintWithOptions lints the rulesets at paths as loaded by LoadWithOptions
+func LintWithOptions(opts Options, paths ...string) ([]LintFinding, error) {
+	l, err := loadLayers(paths, opts)
+	if err != nil {
+		if findings, ok := validationFindings(err); ok {
+			return findings, nil
+		}
+		return nil, err
+	}
+	// a single ruleset is used as is by Load, so it is only merged if there are several
+	if len(l.layers) == 1 {
+		return lintLayer(l.layers[0]), nil
+	}
+	if _, err := mergeLayers(l.layers); err != nil {
+		if findings, ok := validationFindings(err); ok {
+			return findings, nil
+		}
+		return nil, err
+	}
+
+	var result []LintFinding
+	for _, layer := range l.layers {
+		result = append(result, lintLayer(layer)...)
+	}
+	return result, nil
+}
+
+// validationFindings converts validation errors into findings
+func validationFindings(err error) ([]LintFinding, bool) {
+	var errs ValidationErrors
+	switch err := err.(type) {
+	case ValidationErrors:
+		errs = err
+	case ValidationError:
+		errs = ValidationErrors{err}
+	default:
+		return nil, false
+	}
+
+	var result []LintFinding
+	for _, e := range errs {
+		result = append(result, LintFinding{
+			Path:    e.Path,
+			Line:    e.Line,
+			Level:   LintError,
+			Check:   checkInvalid,
+			Message: e.Message,
+		})
+	}
+	return result, true
+}
+
+func lintLayer(l layer) []LintFinding {
+	ln := &linter{
+		path: l.path,
+	}
+	// the ruleset already decoded, so the positions of its values can be decoded as well
+	yaml.Unmarshal(l.data, &ln.root)
+
+	rs := l.ruleset
+	ln.lintSection("createdResources", managedMode, createDeleteLintRules("createdResources", rs.CreatedResources))
+	ln.lintSection("destroyedResources", managedMode, createDeleteLintRules("destroyedResources", rs.DestroyedResources))
+	ln.lintSection("readResources", dataMode, createDeleteLintRules("readResources", rs.ReadResources))
+	ln.lintSection("resources", managedMode, createDeleteLintRules("resources", rs.Resources))
+	ln.lintSection("updatedResources", managedMode, updateLintRules(rs.UpdatedResources))
+
+	ln.lintNoOpOption("createdResources", rs.CreatedResources)
+	ln.lintNoOpOption("destroyedResources", rs.DestroyedResources)
+	ln.lintNoOpOption("readResources", rs.ReadResources)
+	ln.lintNoOpOption("resources", rs.Resources)
+	if rs.DestroyedResources != nil {
+		ln.lintOption([]interface{}{"destroyedResources", "default"}, "ignoreComputed", rs.DestroyedResources.Default != nil && rs.DestroyedResources.Default.IgnoreComputed != nil, "destroyed resources have no computed values")
+		for i, r := range rs.DestroyedResources.Resources {
+			ln.lintOption([]interface{}{"destroyedResources", "resources", i}, "ignoreComputed", r.IgnoreComputed != nil, "destroyed resources have no computed values")
+		}
+	}
+
+	if rs.Variables != nil {
+		for i, r := range rs.Variables.Rules {
+			path := []interface{}{"variables", "rules", i}
+			ln.lintRules(path, withDefaults(r.CompareOptions, rs.Variables.Default), r.ResourceRules)
+		}
+	}
+
+	sort.SliceStable(ln.findings, func(i, j int) bool {
+		return ln.findings[i].Line < ln.findings[j].Line
+	})
+	return ln.findings
+}
+
+func createDeleteLintRules(section string, rules *CreateDeleteResourceChanges) []lintRule {
+	if rules == nil {
+		return nil
+	}
+
+	var result []lintRule
+	for i, r := range rules.Resources {
+		path := []interface{}{section, "resources", i}
+		result = append(result, lintRule{
+			path: path,
+			id:   r.ResourceIdentifier,
+			opts: withDefaults(r.CompareOptions, rules.Default),
+			rules: []lintRules{
+				{path: path, rules: r.ResourceRules},
+			},
+		})
+	}
+	return result
+}
+
+func updateLintRules(rules *UpdateResourceChanges) []lintRule {
+	if rules == nil {
+		return nil
+	}
+
+	var result []lintRule
+	for i, r := range rules.Resources {
+		path := []interface{}{"updatedResources", "resources", i}
+		rule := lintRule{
+			path: path,
+			id:   r.ResourceIdentifier,
+			opts: withDefaults(r.CompareOptions, rules.Default),
+		}
+		if r.Before != nil {
+			rule.rules = append(rule.rules, lintRules{path: appendPath(path, "before"), rules: *r.Before})
+		}
+		if r.After != nil {
+			rule.rules = append(rule.rules, lintRules{path: appendPath(path, "after"), rules: *r.After})
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
+// lintSection lints the rules of a section, which only ever applies to resources of the mode
+func (ln *linter) lintSection(section, mode string, rules []lintRule) {
+	// latest is the rule for each identifier that the comparers use, which is the last one
+	latest := make(map[string]lintRule)
+	replaced := make(map[int]bool)
+	latestIndex := make(map[string]int)
+	for i, r := range rules {
+		key := identifierKey(r.id)
+		if earlier, ok := latest[key]; ok {
+			ln.add(LintError, checkDuplicate, earlier.path, fmt.Sprintf("rule %s is never used, as the rule on line %d has the same identifier and replaces it", describeIdentifier(r.id), ln.root.lineOf(r.path...)))
+			replaced[latestIndex[key]] = true
+		}
+		latest[key] = r
+		latestIndex[key] = i
+	}
+
+	for i, r := range rules {
+		// a replaced rule is never used, so it is only reported as a duplicate
+		if replaced[i] {
+			continue
+		}
+
+		if r.id.Mode != "" && r.id.Mode != mode {
+			ln.add(LintError, checkUnreachable, appendPath(r.path, "mode"), fmt.Sprintf("rule %s never matches, as %s only applies to resources with mode %s", describeIdentifier(r.id), section, mode))
+		}
+
+		// a rule without a mode is only used if there is no rule with the same identifier for the mode
+		if r.id.Mode == "" {
+			specific := r.id
+			specific.Mode = mode
+			if other, ok := latest[identifierKey(specific)]; ok {
+				ln.add(LintError, checkUnreachable, r.path, fmt.Sprintf("rule %s never matches, as the rule on line %d is used for %s resources instead", describeIdentifier(r.id), ln.root.lineOf(other.path...), mode))
+			}
+		}
+
+		// a rule for a type and name is used instead of the rules for only the type or only the name
+		if r.id.Name != "" && r.id.Type != "" {
+			for j, other := range rules {
+				if replaced[j] || other.id.Name != "" && other.id.Type != "" || !modesOverlap(r.id.Mode, other.id.Mode) {
+					continue
+				}
+				if other.id.Type == r.id.Type || other.id.Name == r.id.Name {
+					ln.add(LintWarning, checkShadowed, other.path, fmt.Sprintf("rule %s does not apply to %s.%s, as the rule on line %d is used for it instead", describeIdentifier(other.id), r.id.Type, r.id.Name, ln.root.lineOf(r.path...)))
+				}
+			}
+		}
+
+		for _, rules := range r.rules {
+			ln.lintRules(rules.path, r.opts, rules.rules)
+		}
+	}
+}
+
+// lintRules lints the enforced and ignored arguments of a rule
+func (ln *linter) lintRules(path []interface{}, opts CompareOptions, rules ResourceRules) {
+	if opts.AutoFail != nil && *opts.AutoFail && (len(rules.Enforced) > 0 || len(rules.Ignored) > 0) {
+		ln.add(LintWarning, checkIneffective, path, "autoFail is set, so enforced and ignored arguments are never compared")
+	}
+
+	for _, k := range rules.Ignored {
+		if _, ok := rules.Enforced[k]; ok {
+			ln.add(LintError, checkContradicts, appendPath(path, "enforced", k), fmt.Sprintf("%s is both ignored and enforced, and is never compared as ignored arguments are skipped", k))
+		}
+	}
+
+	keys := make([]string, 0, len(rules.Enforced))
+	for k := range rules.Enforced {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e := rules.Enforced[k]
+		if e.Value == nil && e.MatchAny != nil && len(e.MatchAny) == 0 {
+			ln.add(LintError, checkUnsatisfied, appendPath(path, "enforced", k, "matchAny"), fmt.Sprintf("%s: matchAny is empty, so no value can match", k))
+		}
+	}
+}
+
+// lintNoOpOption reports ignoreNoOp on sections where it has no effect
+func (ln *linter) lintNoOpOption(section string, rules *CreateDeleteResourceChanges) {
+	if rules == nil {
+		return
+	}
+
+	message := "it only applies to updated resources"
+	ln.lintOption([]interface{}{section, "default"}, "ignoreNoOp", rules.Default != nil && rules.Default.IgnoreNoOp != nil, message)
+	for i, r := range rules.Resources {
+		ln.lintOption([]interface{}{section, "resources", i}, "ignoreNoOp", r.IgnoreNoOp != nil, message)
+	}
+}
+
+// lintOption reports an option that is set but has no effect
+func (ln *linter) lintOption(path []interface{}, option string, set bool, reason string) {
+	if set {
+		ln.add(LintWarning, checkIneffective, appendPath(path, option), fmt.Sprintf("%s has no effect, as %s", option, reason))
+	}
+}
+
+func (ln *linter) add(level, check string, path []interface{}, message string) {
+	ln.findings = append(ln.findings, LintFinding{
+		Path:    ln.path,
+		Line:    ln.root.lineOf(path...),
+		Level:   level,
+		Check:   check,
+		Message: message,
+	})
+}
+
+// modesOverlap returns true if rules with the modes can match the same resource
+func modesOverlap(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
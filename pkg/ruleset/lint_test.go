@@ -0,0 +1,99 @@
+package ruleset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	dir := writeRulesets(t, map[string]string{
+		"clean.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        value: us-central1-a
+`,
+		"findings.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+  - type: google_compute_instance
+    ignoreNoOp: true
+    ignored:
+    - zone
+    enforced:
+      zone:
+        value: us-central1-a
+  - type: google_compute_instance
+    name: web
+  - type: google_storage_bucket
+  - type: google_storage_bucket
+    mode: managed
+readResources:
+  resources:
+  - type: google_project
+    mode: managed
+  - name: project
+    autoFail: true
+    enforced:
+      project_id:
+        matchAny: []
+`,
+		"invalid.yaml": `
+createdResources:
+  resources:
+  - typo: google_compute_instance
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		path     string
+		expected []LintFinding
+	}{
+		"no findings": {
+			path: "clean.yaml",
+		},
+		"findings": {
+			path: "findings.yaml",
+			expected: []LintFinding{
+				{Line: 4, Level: LintError, Check: checkDuplicate, Message: "rule google_compute_instance is never used, as the rule on line 5 has the same identifier and replaces it"},
+				{Line: 5, Level: LintWarning, Check: checkShadowed, Message: "rule google_compute_instance does not apply to google_compute_instance.web, as the rule on line 12 is used for it instead"},
+				{Line: 6, Level: LintWarning, Check: checkIneffective, Message: "ignoreNoOp has no effect, as it only applies to updated resources"},
+				{Line: 11, Level: LintError, Check: checkContradicts, Message: "zone is both ignored and enforced, and is never compared as ignored arguments are skipped"},
+				{Line: 14, Level: LintError, Check: checkUnreachable, Message: "rule google_storage_bucket never matches, as the rule on line 15 is used for managed resources instead"},
+				{Line: 20, Level: LintError, Check: checkUnreachable, Message: "rule managed:google_project never matches, as readResources only applies to resources with mode data"},
+				{Line: 21, Level: LintWarning, Check: checkIneffective, Message: "autoFail is set, so enforced and ignored arguments are never compared"},
+				{Line: 25, Level: LintError, Check: checkUnsatisfied, Message: "project_id: matchAny is empty, so no value can match"},
+			},
+		},
+		"invalid ruleset": {
+			path: "invalid.yaml",
+			expected: []LintFinding{
+				{Line: 4, Level: LintError, Check: checkInvalid, Message: `unknown field "typo"`},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.path)
+			got, err := Lint(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for i := range tc.expected {
+				tc.expected[i].Path = path
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Findings mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package ruleset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// rulesetExtensions are the extensions of the files read from a ruleset directory
+var rulesetExtensions = []string{".yaml", ".yml"}
+
+// layer is a single ruleset file to merge, along with the path and contents it was read from
+type layer struct {
+	path    string
+	data    []byte
+	ruleset Ruleset
+}
+
+// Load reads the rulesets at paths, which can be files or directories of YAML files, and merges them into a single ruleset
+// Any problem with a ruleset is returned as ValidationErrors
+func Load(paths ...string) (Ruleset, error) {
+	return LoadWithOptions(Options{}, paths...)
+}
+
+// LoadWithOptions reads the rulesets at paths in the same way as Load, with the parameters and environment of the options
+func LoadWithOptions(opts Options, paths ...string) (Ruleset, error) {
+	l, err := loadLayers(paths, opts)
+	if err != nil {
+		return Ruleset{}, err
+	}
+
+	// a single ruleset is used as is, so its default options still apply to every rule
+	if len(l.layers) == 1 {
+		return l.layers[0].ruleset, nil
+	}
+	return mergeLayers(l.layers)
+}
+
+// loadLayers reads the rulesets at paths and their includes in the order they are merged,
+// and resolves their parameters, definitions, templates and overlays
+func loadLayers(paths []string, opts Options) (*loader, error) {
+	l := &loader{
+		loaded: make(map[string]bool),
+	}
+	for _, path := range paths {
+		if err := l.loadPath(path); err != nil {
+			return nil, err
+		}
+	}
+
+	params, values, err := paramValues(l.layers, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := interpolateLayers(l.layers, params, values); err != nil {
+		return nil, err
+	}
+
+	var errs ValidationErrors
+	for _, layer := range l.layers {
+		if err := validateLayer(layer); err != nil {
+			errs = append(errs, err.(ValidationErrors)...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := resolveLayers(l.layers); err != nil {
+		return nil, err
+	}
+	if err := applyOverlays(l.layers, opts.Env); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// loader reads ruleset files in the order they are merged
+type loader struct {
+	layers []layer
+
+	// loaded are the absolute paths of the files that have been read
+	loaded map[string]bool
+
+	// including are the absolute paths of the files whose includes are being read, to detect cycles
+	including []string
+}
+
+func (l *loader) loadPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return l.loadFile(path)
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && hasRulesetExtension(e.Name()) {
+			files = append(files, filepath.Join(path, e.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no rulesets found in %s", path)
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		if err := l.loadFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *loader) loadFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, p := range l.including {
+		if p == abs {
+			return fmt.Errorf("%s: include cycle: %s", path, strings.Join(append(l.including, abs), " -> "))
+		}
+	}
+	if l.loaded[abs] {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rs, err := decodeRuleset(path, data)
+	if err != nil {
+		return err
+	}
+
+	l.including = append(l.including, abs)
+	for _, include := range rs.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		if err := l.loadPath(include); err != nil {
+			// validation errors already include the path of the ruleset they are in
+			if _, ok := err.(ValidationErrors); ok {
+				return err
+			}
+			return fmt.Errorf("%s: include %s: %v", path, include, err)
+		}
+	}
+	l.including = l.including[:len(l.including)-1]
+
+	l.loaded[abs] = true
+	rs.Include = nil
+	l.layers = append(l.layers, layer{
+		path:    path,
+		data:    data,
+		ruleset: rs,
+	})
+	return nil
+}
+
+func hasRulesetExtension(name string) bool {
+	for _, ext := range rulesetExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package ruleset
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeRulesets(t *testing.T, contents map[string]string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "akashi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range contents {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func boolPointer(b bool) *bool {
+	return &b
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeRulesets(t, map[string]string{
+		"baseline.yaml": `
+createdResources:
+  default:
+    enforceAll: true
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        value: us-central1-a
+  - type: google_storage_bucket
+    enforced:
+      location:
+        value: US
+configuration:
+  toolVersion: ">= 1.0"
+  providers:
+    requireVersion: true
+`,
+		"team.yaml": `
+include:
+- baseline.yaml
+createdResources:
+  strict: true
+  resources:
+  - type: google_compute_instance
+    override: true
+    enforced:
+      zone:
+        value: us-east1-b
+  - name: web
+configuration:
+  toolVersion: "< 2.0"
+`,
+		"duplicate.yaml": `
+include:
+- baseline.yaml
+createdResources:
+  resources:
+  - type: google_compute_instance
+`,
+		"stale-override.yaml": `
+createdResources:
+  resources:
+  - type: google_sql_database_instance
+    override: true
+`,
+		"cycle-a.yaml": "include:\n- cycle-b.yaml\n",
+		"cycle-b.yaml": "include:\n- cycle-a.yaml\n",
+		"tool-a.yaml":  "configuration:\n  tool: terraform\n",
+		"tool-b.yaml":  "configuration:\n  tool: opentofu\n",
+		"rules/a.yaml": "createdResources:\n  resources:\n  - type: a\n",
+		"rules/b.yml":  "createdResources:\n  resources:\n  - type: b\n",
+		"rules/c.txt":  "not a ruleset",
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		paths    []string
+		expected Ruleset
+		err      string
+	}{
+		"single ruleset is used as is": {
+			paths: []string{"rules/a.yaml"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{ResourceIdentifier: ResourceIdentifier{Type: "a"}},
+					},
+				},
+			},
+		},
+		"included ruleset is layered under the including ruleset": {
+			paths: []string{"team.yaml"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Strict: true,
+					Resources: []CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance", Override: true},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{"zone": {Value: "us-east1-b"}},
+							},
+						},
+						{
+							CompareOptions:     CompareOptions{EnforceAll: boolPointer(true)},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_storage_bucket"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{"location": {Value: "US"}},
+							},
+						},
+						{ResourceIdentifier: ResourceIdentifier{Name: "web"}},
+					},
+				},
+				Configuration: &ConfigurationRules{
+					ToolVersion: ">= 1.0, < 2.0",
+					Providers: &ProviderRules{
+						RequireVersion: true,
+					},
+				},
+			},
+		},
+		"included ruleset is only read once": {
+			paths: []string{"baseline.yaml", "team.yaml"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Strict: true,
+					Resources: []CreateDeleteResourceChange{
+						{
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance", Override: true},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{"zone": {Value: "us-east1-b"}},
+							},
+						},
+						{
+							CompareOptions:     CompareOptions{EnforceAll: boolPointer(true)},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_storage_bucket"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{"location": {Value: "US"}},
+							},
+						},
+						{ResourceIdentifier: ResourceIdentifier{Name: "web"}},
+					},
+				},
+				Configuration: &ConfigurationRules{
+					ToolVersion: ">= 1.0, < 2.0",
+					Providers: &ProviderRules{
+						RequireVersion: true,
+					},
+				},
+			},
+		},
+		"directory": {
+			paths: []string{"rules"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{ResourceIdentifier: ResourceIdentifier{Type: "a"}},
+						{ResourceIdentifier: ResourceIdentifier{Type: "b"}},
+					},
+				},
+			},
+		},
+		"duplicate rule without override": {
+			paths: []string{"duplicate.yaml"},
+			err:   "createdResources rule google_compute_instance is already set by",
+		},
+		"override without an earlier rule": {
+			paths: []string{"baseline.yaml", "stale-override.yaml"},
+			err:   "sets override, but there is no earlier rule to replace",
+		},
+		"include cycle": {
+			paths: []string{"cycle-a.yaml"},
+			err:   "include cycle",
+		},
+		"conflicting tools": {
+			paths: []string{"tool-a.yaml", "tool-b.yaml"},
+			err:   "configuration tool is already set to terraform",
+		},
+		"missing ruleset": {
+			paths: []string{"missing.yaml"},
+			err:   "no such file or directory",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			for _, p := range tc.paths {
+				paths = append(paths, filepath.Join(dir, p))
+			}
+
+			got, err := Load(paths...)
+			if tc.err != "" {
+				if err == nil {
+					t.Fatalf("Expected an error but got none")
+				}
+				if !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("Expected error to contain %q but got: %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Ruleset mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
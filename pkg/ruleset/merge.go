@@ -0,0 +1,304 @@
+package ruleset
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Merge semantics, applied to each ruleset in turn on top of the ones before it:
+//   - strict is enabled if any ruleset enables it
+//   - default options only apply to the rules of the ruleset they are written in
+//   - resource rules are combined, and a rule with the same mode, type and name as a rule
+//     from an earlier ruleset is an error unless it sets override, which replaces the earlier rule
+//   - variables rules are combined, as every variables rule is applied
+//   - configuration booleans are enabled if any ruleset enables them, version constraints must all be met,
+//     and the tool, allowed providers and allowed module sources can only be set to one value
+//   - waivers are combined
+
+// merger layers rulesets on top of each other
+type merger struct {
+	result Ruleset
+
+	// origins are the paths of the rulesets that set each resource rule and configuration value
+	origins map[string]string
+}
+
+// mergeLayers merges the rulesets in order, with later rulesets layered on top of earlier ones
+func mergeLayers(layers []layer) (Ruleset, error) {
+	m := &merger{
+		origins: make(map[string]string),
+	}
+
+	for _, l := range layers {
+		if err := m.merge(l.path, l.ruleset); err != nil {
+			return Ruleset{}, err
+		}
+	}
+
+	return m.result, nil
+}
+
+func (m *merger) merge(path string, rs Ruleset) error {
+	var err error
+	if m.result.CreatedResources, err = m.mergeCreateDelete("createdResources", path, m.result.CreatedResources, rs.CreatedResources); err != nil {
+		return err
+	}
+	if m.result.DestroyedResources, err = m.mergeCreateDelete("destroyedResources", path, m.result.DestroyedResources, rs.DestroyedResources); err != nil {
+		return err
+	}
+	if m.result.ReadResources, err = m.mergeCreateDelete("readResources", path, m.result.ReadResources, rs.ReadResources); err != nil {
+		return err
+	}
+	if m.result.Resources, err = m.mergeCreateDelete("resources", path, m.result.Resources, rs.Resources); err != nil {
+		return err
+	}
+	if m.result.UpdatedResources, err = m.mergeUpdate(path, m.result.UpdatedResources, rs.UpdatedResources); err != nil {
+		return err
+	}
+	m.result.Variables = mergeVariables(m.result.Variables, rs.Variables)
+	m.result.Waivers = append(m.result.Waivers, rs.Waivers...)
+	if m.result.Configuration, err = m.mergeConfiguration(path, m.result.Configuration, rs.Configuration); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *merger) mergeCreateDelete(section, path string, dst, src *CreateDeleteResourceChanges) (*CreateDeleteResourceChanges, error) {
+	if src == nil {
+		return dst, nil
+	}
+	if dst == nil {
+		dst = &CreateDeleteResourceChanges{}
+	}
+
+	dst.Strict = dst.Strict || src.Strict
+	for _, r := range src.Resources {
+		r.CompareOptions = withDefaults(r.CompareOptions, src.Default)
+
+		i := -1
+		for j, existing := range dst.Resources {
+			if identifierKey(existing.ResourceIdentifier) == identifierKey(r.ResourceIdentifier) {
+				i = j
+			}
+		}
+		if err := m.checkOverride(section, path, r.ResourceIdentifier, i >= 0); err != nil {
+			return nil, err
+		}
+
+		if i >= 0 {
+			dst.Resources[i] = r
+		} else {
+			dst.Resources = append(dst.Resources, r)
+		}
+	}
+
+	return dst, nil
+}
+
+func (m *merger) mergeUpdate(path string, dst, src *UpdateResourceChanges) (*UpdateResourceChanges, error) {
+	if src == nil {
+		return dst, nil
+	}
+	if dst == nil {
+		dst = &UpdateResourceChanges{}
+	}
+
+	dst.Strict = dst.Strict || src.Strict
+	for _, r := range src.Resources {
+		r.CompareOptions = withDefaults(r.CompareOptions, src.Default)
+
+		i := -1
+		for j, existing := range dst.Resources {
+			if identifierKey(existing.ResourceIdentifier) == identifierKey(r.ResourceIdentifier) {
+				i = j
+			}
+		}
+		if err := m.checkOverride("updatedResources", path, r.ResourceIdentifier, i >= 0); err != nil {
+			return nil, err
+		}
+
+		if i >= 0 {
+			dst.Resources[i] = r
+		} else {
+			dst.Resources = append(dst.Resources, r)
+		}
+	}
+
+	return dst, nil
+}
+
+// checkOverride returns an error if a rule replaces a rule from an earlier ruleset without setting override,
+// or sets override without replacing a rule
+func (m *merger) checkOverride(section, path string, id ResourceIdentifier, exists bool) error {
+	key := fmt.Sprintf("%s %s", section, identifierKey(id))
+	origin := m.origins[key]
+	m.origins[key] = path
+
+	if exists && origin != path && !id.Override {
+		return ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("%s rule %s is already set by %s, set override to replace it", section, describeIdentifier(id), origin),
+		}
+	}
+	if !exists && id.Override {
+		return ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("%s rule %s sets override, but there is no earlier rule to replace", section, describeIdentifier(id)),
+		}
+	}
+	return nil
+}
+
+func mergeVariables(dst, src *VariableRules) *VariableRules {
+	if src == nil {
+		return dst
+	}
+	if dst == nil {
+		dst = &VariableRules{}
+	}
+
+	for _, r := range src.Rules {
+		r.CompareOptions = withDefaults(r.CompareOptions, src.Default)
+		dst.Rules = append(dst.Rules, r)
+	}
+
+	return dst
+}
+
+func (m *merger) mergeConfiguration(path string, dst, src *ConfigurationRules) (*ConfigurationRules, error) {
+	if src == nil {
+		return dst, nil
+	}
+	if dst == nil {
+		dst = &ConfigurationRules{}
+	}
+
+	dst.ToolVersion = joinConstraints(dst.ToolVersion, src.ToolVersion)
+	for tool, constraint := range src.ToolVersions {
+		if dst.ToolVersions == nil {
+			dst.ToolVersions = make(map[string]string)
+		}
+		dst.ToolVersions[tool] = joinConstraints(dst.ToolVersions[tool], constraint)
+	}
+
+	if src.Tool != "" {
+		if err := m.setOnce(path, "configuration tool", dst.Tool, src.Tool); err != nil {
+			return nil, err
+		}
+		dst.Tool = src.Tool
+	}
+
+	if src.Providers != nil {
+		if dst.Providers == nil {
+			dst.Providers = &ProviderRules{}
+		}
+		if len(src.Providers.Allowed) > 0 {
+			if err := m.setOnce(path, "configuration allowed providers", sortedStrings(dst.Providers.Allowed), sortedStrings(src.Providers.Allowed)); err != nil {
+				return nil, err
+			}
+			dst.Providers.Allowed = src.Providers.Allowed
+		}
+		dst.Providers.RequireVersion = dst.Providers.RequireVersion || src.Providers.RequireVersion
+	}
+
+	if src.Modules != nil {
+		if dst.Modules == nil {
+			dst.Modules = &ModuleRules{}
+		}
+		if len(src.Modules.AllowedSources) > 0 {
+			if err := m.setOnce(path, "configuration allowed module sources", sortedStrings(dst.Modules.AllowedSources), sortedStrings(src.Modules.AllowedSources)); err != nil {
+				return nil, err
+			}
+			dst.Modules.AllowedSources = src.Modules.AllowedSources
+		}
+		dst.Modules.DenyLocal = dst.Modules.DenyLocal || src.Modules.DenyLocal
+		dst.Modules.RequireVersion = dst.Modules.RequireVersion || src.Modules.RequireVersion
+		dst.Modules.RequirePinnedVersion = dst.Modules.RequirePinnedVersion || src.Modules.RequirePinnedVersion
+	}
+
+	return dst, nil
+}
+
+// setOnce returns an error if a value set by an earlier ruleset is set to a different value
+func (m *merger) setOnce(path, name string, existing, value interface{}) error {
+	origin, ok := m.origins[name]
+	if ok && !reflect.DeepEqual(existing, value) {
+		return ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf("%s is already set to %v by %s", name, existing, origin),
+		}
+	}
+	if !ok {
+		m.origins[name] = path
+	}
+	return nil
+}
+
+// withDefaults returns the options with every unset option taken from the default options
+func withDefaults(opts CompareOptions, defaults *CompareOptions) CompareOptions {
+	if defaults == nil {
+		return opts
+	}
+
+	if opts.EnforceAll == nil {
+		opts.EnforceAll = defaults.EnforceAll
+	}
+	if opts.IgnoreExtraArgs == nil {
+		opts.IgnoreExtraArgs = defaults.IgnoreExtraArgs
+	}
+	if opts.IgnoreComputed == nil {
+		opts.IgnoreComputed = defaults.IgnoreComputed
+	}
+	if opts.RequireAll == nil {
+		opts.RequireAll = defaults.RequireAll
+	}
+	if opts.AutoFail == nil {
+		opts.AutoFail = defaults.AutoFail
+	}
+	if opts.IgnoreNoOp == nil {
+		opts.IgnoreNoOp = defaults.IgnoreNoOp
+	}
+	return opts
+}
+
+// joinConstraints combines two version constraints so that both must be met
+func joinConstraints(a, b string) string {
+	if a == "" || a == b {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return fmt.Sprintf("%s, %s", a, b)
+}
+
+func sortedStrings(values []string) []string {
+	result := append([]string(nil), values...)
+	sort.Strings(result)
+	return result
+}
+
+// identifierKey is the key a rule is stored under by the comparers
+func identifierKey(id ResourceIdentifier) string {
+	return fmt.Sprintf("%s:%s.%s", id.Mode, id.Type, id.Name)
+}
+
+// describeIdentifier describes the resources a rule applies to, such as "google_compute_instance.web" or "data:google_project"
+func describeIdentifier(id ResourceIdentifier) string {
+	var result string
+	switch {
+	case id.Type != "" && id.Name != "":
+		result = fmt.Sprintf("%s.%s", id.Type, id.Name)
+	case id.Name != "":
+		result = id.Name
+	default:
+		result = id.Type
+	}
+
+	if id.Mode != "" {
+		result = fmt.Sprintf("%s:%s", id.Mode, result)
+	}
+	return result
+}
@@ -0,0 +1,157 @@
+package ruleset
+
+// ruleSections are the sections of rules of a ruleset or an overlay
+type ruleSections struct {
+	created   *CreateDeleteResourceChanges
+	destroyed *CreateDeleteResourceChanges
+	read      *CreateDeleteResourceChanges
+	resources *CreateDeleteResourceChanges
+	updated   *UpdateResourceChanges
+	variables *VariableRules
+}
+
+func (rs Ruleset) sections() ruleSections {
+	return ruleSections{
+		created:   rs.CreatedResources,
+		destroyed: rs.DestroyedResources,
+		read:      rs.ReadResources,
+		resources: rs.Resources,
+		updated:   rs.UpdatedResources,
+		variables: rs.Variables,
+	}
+}
+
+func (o Overlay) sections() ruleSections {
+	return ruleSections{
+		created:   o.CreatedResources,
+		destroyed: o.DestroyedResources,
+		read:      o.ReadResources,
+		resources: o.Resources,
+		updated:   o.UpdatedResources,
+		variables: o.Variables,
+	}
+}
+
+// applyOverlay returns the ruleset with its rules patched by the overlay
+func applyOverlay(rs Ruleset, o Overlay) Ruleset {
+	rs.CreatedResources = patchCreateDelete(rs.CreatedResources, o.CreatedResources)
+	rs.DestroyedResources = patchCreateDelete(rs.DestroyedResources, o.DestroyedResources)
+	rs.ReadResources = patchCreateDelete(rs.ReadResources, o.ReadResources)
+	rs.Resources = patchCreateDelete(rs.Resources, o.Resources)
+	rs.UpdatedResources = patchUpdate(rs.UpdatedResources, o.UpdatedResources)
+	rs.Variables = patchVariables(rs.Variables, o.Variables)
+	return rs
+}
+
+func patchCreateDelete(base, overlay *CreateDeleteResourceChanges) *CreateDeleteResourceChanges {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		return overlay
+	}
+
+	result := &CreateDeleteResourceChanges{
+		Strict:    base.Strict || overlay.Strict,
+		Default:   patchOptions(base.Default, overlay.Default),
+		Resources: append([]CreateDeleteResourceChange(nil), base.Resources...),
+	}
+	for _, r := range overlay.Resources {
+		i := -1
+		for j, existing := range result.Resources {
+			if identifierKey(existing.ResourceIdentifier) == identifierKey(r.ResourceIdentifier) {
+				i = j
+			}
+		}
+		if i < 0 {
+			result.Resources = append(result.Resources, r)
+			continue
+		}
+
+		b := result.Resources[i]
+		r.RuleMetadata = extendMetadata(r.RuleMetadata, b.RuleMetadata)
+		r.CompareOptions = withDefaults(r.CompareOptions, &b.CompareOptions)
+		r.ResourceRules = extendRules(r.ResourceRules, b.ResourceRules)
+		r.Override = r.Override || b.Override
+		result.Resources[i] = r
+	}
+
+	return result
+}
+
+func patchUpdate(base, overlay *UpdateResourceChanges) *UpdateResourceChanges {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		return overlay
+	}
+
+	result := &UpdateResourceChanges{
+		Strict:    base.Strict || overlay.Strict,
+		Default:   patchOptions(base.Default, overlay.Default),
+		Resources: append([]UpdateResourceChange(nil), base.Resources...),
+	}
+	for _, r := range overlay.Resources {
+		i := -1
+		for j, existing := range result.Resources {
+			if identifierKey(existing.ResourceIdentifier) == identifierKey(r.ResourceIdentifier) {
+				i = j
+			}
+		}
+		if i < 0 {
+			result.Resources = append(result.Resources, r)
+			continue
+		}
+
+		b := result.Resources[i]
+		r.RuleMetadata = extendMetadata(r.RuleMetadata, b.RuleMetadata)
+		r.CompareOptions = withDefaults(r.CompareOptions, &b.CompareOptions)
+		r.Before = patchRules(b.Before, r.Before)
+		r.After = patchRules(b.After, r.After)
+		r.Override = r.Override || b.Override
+		result.Resources[i] = r
+	}
+
+	return result
+}
+
+// patchVariables adds the variables rules of the overlay, as variables rules have no identifier to patch
+func patchVariables(base, overlay *VariableRules) *VariableRules {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		return overlay
+	}
+
+	return &VariableRules{
+		Default: patchOptions(base.Default, overlay.Default),
+		Rules:   append(append([]VariableRule(nil), base.Rules...), overlay.Rules...),
+	}
+}
+
+// patchOptions returns the options of the overlay, with every unset option taken from the base options
+func patchOptions(base, overlay *CompareOptions) *CompareOptions {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		return overlay
+	}
+
+	result := withDefaults(*overlay, base)
+	return &result
+}
+
+func patchRules(base, overlay *ResourceRules) *ResourceRules {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		return overlay
+	}
+
+	result := extendRules(*overlay, *base)
+	return &result
+}
@@ -0,0 +1,424 @@
+package ruleset
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ParamEnvPrefix is the prefix of the environment variables parameters are read from
+// Example: AKASHI_VAR_region sets the region parameter
+const ParamEnvPrefix = "AKASHI_VAR_"
+
+// Parameter types
+const (
+	ParamString = "string"
+	ParamNumber = "number"
+	ParamBool   = "bool"
+	ParamList   = "list"
+)
+
+// validParamTypes are the values a parameter's type can be set to
+var validParamTypes = []string{"", ParamString, ParamNumber, ParamBool, ParamList}
+
+// paramNamePattern matches the names of parameters
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// paramRefPattern matches a reference to a parameter in a string, or an escaped reference written as $${var.name}
+var paramRefPattern = regexp.MustCompile(`(\$?)\$\{var\.([^}]*)\}`)
+
+// wholeParamRefPattern matches a string that is only a reference to a parameter, which is replaced with the value as is
+var wholeParamRefPattern = regexp.MustCompile(`^\$\{var\.([^}]*)\}$`)
+
+// notInterpolatedFields are the fields that are not interpolated
+var notInterpolatedFields = map[string]bool{
+	"include":    true,
+	"parameters": true,
+	"vars":       true,
+}
+
+// Options are the parameter values and environment rulesets are loaded with
+type Options struct {
+	// Vars are the values of parameters, which take priority over every other value
+	Vars map[string]string
+
+	// VarFiles are YAML files of parameter values, where later files take priority over earlier ones
+	VarFiles []string
+
+	// LookupEnv looks up the environment variables parameters are read from, such as os.LookupEnv
+	LookupEnv func(string) (string, bool)
+
+	// Env is the environment whose overlays are applied
+	Env string
+}
+
+// paramType returns the type of a parameter, where an empty type is a string
+func paramType(t string) string {
+	if t == "" {
+		return ParamString
+	}
+	return t
+}
+
+// isParamType returns true if the value is of the parameter type
+func isParamType(t string, v interface{}) bool {
+	switch paramType(t) {
+	case ParamString:
+		_, ok := v.(string)
+		return ok
+	case ParamNumber:
+		switch v.(type) {
+		case int, int64, uint64, float64:
+			return true
+		}
+		return false
+	case ParamBool:
+		_, ok := v.(bool)
+		return ok
+	case ParamList:
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return false
+}
+
+// parseParamValue parses a value given as a string, where types other than string are parsed as YAML
+func parseParamValue(name string, p Parameter, s string) (interface{}, error) {
+	if paramType(p.Type) == ParamString {
+		return s, nil
+	}
+
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || !isParamType(p.Type, v) {
+		return nil, fmt.Errorf("invalid value %q for parameter %q, must be a %s", s, name, paramType(p.Type))
+	}
+	return v, nil
+}
+
+// readVarFile reads a YAML file of parameter values
+func readVarFile(path string, params map[string]Parameter) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var vars map[string]interface{}
+	if err := yaml.UnmarshalStrict(data, &vars); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for name, v := range vars {
+		p, ok := params[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown parameter %q", path, name)
+		}
+		if !isParamType(p.Type, v) {
+			return nil, fmt.Errorf("%s: value of parameter %q must be a %s", path, name, paramType(p.Type))
+		}
+	}
+	return vars, nil
+}
+
+// applyOverlays patches the rules of each layer with its overlay for the environment, and removes every overlay and parameter
+func applyOverlays(layers []layer, env string) error {
+	found := false
+	for i := range layers {
+		rs := &layers[i].ruleset
+		if o, ok := rs.Overlays[env]; ok && env != "" {
+			found = true
+			*rs = applyOverlay(*rs, o)
+		}
+		rs.Overlays = nil
+		rs.Parameters = nil
+	}
+
+	if env != "" && !found {
+		return fmt.Errorf("no ruleset has an overlay for environment %q", env)
+	}
+	return nil
+}
+
+// paramValues returns the value of every parameter of the layers, or nil for a parameter without one
+// Values are taken from the vars, the var files, environment variables, the overlays and the defaults, in that order
+func paramValues(layers []layer, opts Options) (map[string]Parameter, map[string]interface{}, error) {
+	params := make(map[string]Parameter)
+	origins := make(map[string]int)
+	var errs ValidationErrors
+	for i, l := range layers {
+		var problems []problem
+		for _, name := range sortedParams(l.ruleset.Parameters) {
+			path := []interface{}{"parameters", name}
+			problems = append(problems, validateParameter(path, name, l.ruleset.Parameters[name])...)
+			if origin, ok := origins[name]; ok {
+				problems = append(problems, problem{
+					path:    path,
+					message: fmt.Sprintf("parameter %q is already declared in %s", name, layers[origin].path),
+				})
+				continue
+			}
+			params[name] = l.ruleset.Parameters[name]
+			origins[name] = i
+		}
+		errs = append(errs, l.errors(problems)...)
+	}
+
+	values := make(map[string]interface{})
+	for name, p := range params {
+		values[name] = p.Default
+	}
+
+	// the vars of every overlay are checked, but only the overlay of the environment sets values
+	for _, l := range layers {
+		var problems []problem
+		envs := make([]string, 0, len(l.ruleset.Overlays))
+		for env := range l.ruleset.Overlays {
+			envs = append(envs, env)
+		}
+		sort.Strings(envs)
+		for _, env := range envs {
+			o := l.ruleset.Overlays[env]
+			for name, v := range o.Vars {
+				path := []interface{}{"overlays", env, "vars", name}
+				p, ok := params[name]
+				if !ok {
+					problems = append(problems, problem{path: path, message: fmt.Sprintf("unknown parameter %q", name)})
+					continue
+				}
+				if !isParamType(p.Type, v) {
+					problems = append(problems, problem{path: path, message: fmt.Sprintf("value of parameter %q must be a %s", name, paramType(p.Type))})
+					continue
+				}
+				if env == opts.Env {
+					values[name] = v
+				}
+			}
+		}
+		errs = append(errs, l.errors(problems)...)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	if opts.LookupEnv != nil {
+		for name, p := range params {
+			s, ok := opts.LookupEnv(ParamEnvPrefix + name)
+			if !ok {
+				continue
+			}
+			v, err := parseParamValue(name, p, s)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s%s: %v", ParamEnvPrefix, name, err)
+			}
+			values[name] = v
+		}
+	}
+
+	for _, path := range opts.VarFiles {
+		vars, err := readVarFile(path, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, v := range vars {
+			values[name] = v
+		}
+	}
+
+	for name, s := range opts.Vars {
+		p, ok := params[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown parameter %q, it must be declared under parameters in a ruleset", name)
+		}
+		v, err := parseParamValue(name, p, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[name] = v
+	}
+
+	return params, values, nil
+}
+
+func sortedParams(params map[string]Parameter) []string {
+	result := make([]string, 0, len(params))
+	for name := range params {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// interpolator replaces the references to parameters in the strings of a ruleset with their values
+type interpolator struct {
+	params   map[string]Parameter
+	values   map[string]interface{}
+	problems []problem
+}
+
+// interpolateLayers replaces the references to parameters in every layer
+func interpolateLayers(layers []layer, params map[string]Parameter, values map[string]interface{}) error {
+	var errs ValidationErrors
+	for i := range layers {
+		in := &interpolator{
+			params: params,
+			values: values,
+		}
+		in.walk(reflect.ValueOf(&layers[i].ruleset).Elem(), nil)
+		errs = append(errs, layers[i].errors(in.problems)...)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// walk interpolates every string in v, which must be settable, where path is the path of v in the ruleset
+func (in *interpolator) walk(v reflect.Value, path []interface{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			in.walk(v.Elem(), path)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, inline := yamlFieldName(f)
+			if name == "-" || notInterpolatedFields[name] {
+				continue
+			}
+			if inline {
+				in.walk(v.Field(i), path)
+				continue
+			}
+			in.walk(v.Field(i), appendPath(path, name))
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Interface && !v.IsNil() {
+			v.Set(reflect.ValueOf(in.spliceLists(v.Interface().([]interface{}))))
+		}
+		for i := 0; i < v.Len(); i++ {
+			in.walk(v.Index(i), appendPath(path, i))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			in.walk(elem, appendPath(path, fmt.Sprint(k.Interface())))
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.String:
+		v.SetString(in.interpolateString(v.String(), path))
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		switch value := v.Interface().(type) {
+		case string:
+			v.Set(reflect.ValueOf(in.interpolateValue(value, path)))
+		case []interface{}:
+			list := reflect.ValueOf(in.spliceLists(value))
+			for i := 0; i < list.Len(); i++ {
+				in.walk(list.Index(i), appendPath(path, i))
+			}
+			v.Set(list)
+		case map[interface{}]interface{}:
+			in.walk(reflect.ValueOf(value), path)
+		}
+	}
+}
+
+// spliceLists replaces each item of a list of values that is only a reference to a list parameter with the items of the parameter
+func (in *interpolator) spliceLists(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			if match := wholeParamRefPattern.FindStringSubmatch(s); match != nil {
+				if list, ok := in.values[match[1]].([]interface{}); ok {
+					result = append(result, list...)
+					continue
+				}
+			}
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// interpolateValue interpolates a string where any value is allowed
+func (in *interpolator) interpolateValue(s string, path []interface{}) interface{} {
+	if match := wholeParamRefPattern.FindStringSubmatch(s); match != nil {
+		if v := in.lookup(match[1], path); v != nil {
+			return v
+		}
+		return s
+	}
+	return in.interpolateString(s, path)
+}
+
+// interpolateString replaces every reference in the string with the value of the parameter
+func (in *interpolator) interpolateString(s string, path []interface{}) string {
+	return paramRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		match := paramRefPattern.FindStringSubmatch(ref)
+		if match[1] == "$" {
+			return ref[1:]
+		}
+
+		v := in.lookup(match[2], path)
+		switch v.(type) {
+		case nil:
+			return ref
+		case []interface{}:
+			in.problems = append(in.problems, problem{
+				path:    path,
+				message: fmt.Sprintf("parameter %q is a list, and can only be used as a value or an item of a list of values", match[2]),
+			})
+			return ref
+		}
+		return fmt.Sprint(v)
+	})
+}
+
+// lookup returns the value of the parameter, or nil if it is unknown or has no value
+func (in *interpolator) lookup(name string, path []interface{}) interface{} {
+	if _, ok := in.params[name]; !ok {
+		in.problems = append(in.problems, problem{
+			path:    path,
+			message: fmt.Sprintf("unknown parameter %q", name),
+		})
+		return nil
+	}
+
+	v := in.values[name]
+	if v == nil {
+		in.problems = append(in.problems, problem{
+			path:    path,
+			message: fmt.Sprintf("parameter %q has no value and no default", name),
+		})
+	}
+	return v
+}
+
+// yamlFieldName returns the name of a struct field in YAML, and whether it is inlined
+func yamlFieldName(f reflect.StructField) (string, bool) {
+	parts := strings.Split(f.Tag.Get("yaml"), ",")
+	name := parts[0]
+	inline := false
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			inline = true
+		}
+	}
+
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	return name, inline
+}
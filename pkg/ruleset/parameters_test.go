@@ -0,0 +1,330 @@
+package ruleset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadWithOptions(t *testing.T) {
+	dir := writeRulesets(t, map[string]string{
+		"params.yaml": `
+parameters:
+  region:
+    description: region resources are created in
+    default: us-central1
+  machineTypes:
+    type: list
+    default: [n1-standard-1]
+  diskSize:
+    type: number
+  severity:
+    default: error
+createdResources:
+  resources:
+  - type: google_compute_instance
+    severity: ${var.severity}
+    enforced:
+      zone:
+        match: ^${var.region}-[a-z]$
+      machine_type:
+        matchAny:
+        - ${var.machineTypes}
+        - e2-micro
+      disk_size:
+        value: ${var.diskSize}
+      description:
+        value: costs $${var.region}
+`,
+		"prod.vars.yaml": `
+diskSize: 100
+region: europe-west1
+`,
+		"overlays.yaml": `
+parameters:
+  region:
+    default: us-central1
+  owner:
+    type: string
+createdResources:
+  resources:
+  - type: google_compute_instance
+    id: approved-instances
+    owner: ${var.owner}
+    enforced:
+      zone:
+        match: ^${var.region}-
+templates:
+  labelled:
+    enforced:
+      labels:
+        value: {env: prod}
+overlays:
+  prod:
+    vars:
+      region: europe-west1
+      owner: prod-team
+    createdResources:
+      resources:
+      - type: google_compute_instance
+        severity: warning
+        enforced:
+          machine_type:
+            value: n1-standard-4
+      - type: google_storage_bucket
+        extends: labelled
+  dev:
+    vars:
+      owner: dev-team
+`,
+		"errors.yaml": `
+parameters:
+  unset: {}
+  zones:
+    type: list
+    default: [a]
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        value: ${var.unset}
+      region:
+        value: ${var.missing}
+      name:
+        value: zone-${var.zones}
+`,
+		"invalid.yaml": `
+parameters:
+  1region:
+    type: map
+  size:
+    type: number
+    default: large
+overlays:
+  prod:
+    vars:
+      missing: true
+`,
+		"invalid-value.yaml": `
+parameters:
+  severity:
+    default: critical
+  prefix:
+    default: disk-(
+createdResources:
+  resources:
+  - type: google_compute_instance
+    severity: ${var.severity}
+  - type: google_compute_disk
+    enforced:
+      name:
+        match: ^${var.prefix}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		paths    []string
+		opts     Options
+		expected Ruleset
+		err      []string
+	}{
+		"defaults, vars and escapes": {
+			paths: []string{"params.yaml"},
+			opts: Options{
+				Vars: map[string]string{"diskSize": "50", "machineTypes": "[n2-standard-2, n2-standard-4]"},
+			},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{
+							RuleMetadata:       RuleMetadata{Severity: "error"},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"zone":         {Match: "^us-central1-[a-z]$"},
+									"machine_type": {MatchAny: []interface{}{"n2-standard-2", "n2-standard-4", "e2-micro"}},
+									"disk_size":    {Value: 50},
+									"description":  {Value: "costs ${var.region}"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"vars take priority over var files and environment variables": {
+			paths: []string{"params.yaml"},
+			opts: Options{
+				Vars:     map[string]string{"severity": "warning"},
+				VarFiles: []string{"prod.vars.yaml"},
+				LookupEnv: func(name string) (string, bool) {
+					values := map[string]string{
+						"AKASHI_VAR_region":   "asia-east1",
+						"AKASHI_VAR_severity": "info",
+					}
+					v, ok := values[name]
+					return v, ok
+				},
+			},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{
+							RuleMetadata:       RuleMetadata{Severity: "warning"},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"zone":         {Match: "^europe-west1-[a-z]$"},
+									"machine_type": {MatchAny: []interface{}{"n1-standard-1", "e2-micro"}},
+									"disk_size":    {Value: 100},
+									"description":  {Value: "costs ${var.region}"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"overlay patches and adds rules": {
+			paths: []string{"overlays.yaml"},
+			opts:  Options{Env: "prod"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{
+							RuleMetadata: RuleMetadata{
+								ID:       "approved-instances",
+								Severity: "warning",
+								Owner:    "prod-team",
+							},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"zone":         {Match: "^europe-west1-"},
+									"machine_type": {Value: "n1-standard-4"},
+								},
+							},
+						},
+						{
+							ResourceIdentifier: ResourceIdentifier{Type: "google_storage_bucket"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"labels": {Value: map[interface{}]interface{}{"env": "prod"}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"overlay without rules only sets vars": {
+			paths: []string{"overlays.yaml"},
+			opts:  Options{Env: "dev"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{
+							RuleMetadata: RuleMetadata{
+								ID:    "approved-instances",
+								Owner: "dev-team",
+							},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"zone": {Match: "^us-central1-"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"unset parameter without an environment": {
+			paths: []string{"overlays.yaml"},
+			err:   []string{`overlays.yaml:11: parameter "owner" has no value and no default`},
+		},
+		"unknown environment": {
+			paths: []string{"params.yaml"},
+			opts:  Options{Env: "staging", Vars: map[string]string{"diskSize": "10"}},
+			err:   []string{`no ruleset has an overlay for environment "staging"`},
+		},
+		"unknown var": {
+			paths: []string{"params.yaml"},
+			opts:  Options{Vars: map[string]string{"zone": "a"}},
+			err:   []string{`unknown parameter "zone"`},
+		},
+		"invalid var": {
+			paths: []string{"params.yaml"},
+			opts:  Options{Vars: map[string]string{"diskSize": "large"}},
+			err:   []string{`invalid value "large" for parameter "diskSize", must be a number`},
+		},
+		"unknown var in var file": {
+			paths: []string{"overlays.yaml"},
+			opts:  Options{VarFiles: []string{"prod.vars.yaml"}, Vars: map[string]string{"owner": "me"}},
+			err:   []string{`prod.vars.yaml: unknown parameter "diskSize"`},
+		},
+		"unresolved references": {
+			paths: []string{"errors.yaml"},
+			err: []string{
+				`errors.yaml:12: parameter "unset" has no value and no default`,
+				`errors.yaml:14: unknown parameter "missing"`,
+				`errors.yaml:16: parameter "zones" is a list, and can only be used as a value or an item of a list of values`,
+			},
+		},
+		"invalid parameters": {
+			paths: []string{"invalid.yaml"},
+			err: []string{
+				`invalid.yaml:4: invalid parameter name "1region"`,
+				`invalid.yaml:4: invalid parameter type "map", must be string, number, bool or list`,
+				`invalid.yaml:7: default of parameter "size" must be a number`,
+				`invalid.yaml:11: unknown parameter "missing"`,
+			},
+		},
+		"interpolated values are validated": {
+			paths: []string{"invalid-value.yaml"},
+			err: []string{
+				`invalid-value.yaml:10: invalid severity "critical", must be error, warning or info`,
+				"invalid-value.yaml:14: name: invalid match pattern: error parsing regexp: missing closing ): `^disk-(`",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			for _, p := range tc.paths {
+				paths = append(paths, filepath.Join(dir, p))
+			}
+			opts := tc.opts
+			var varFiles []string
+			for _, p := range opts.VarFiles {
+				varFiles = append(varFiles, filepath.Join(dir, p))
+			}
+			opts.VarFiles = varFiles
+
+			got, err := LoadWithOptions(opts, paths...)
+			if len(tc.err) > 0 {
+				if err == nil {
+					t.Fatalf("Expected an error but got none")
+				}
+				for _, e := range tc.err {
+					if !strings.Contains(err.Error(), e) {
+						t.Errorf("Expected error to contain %q but got: %v", e, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Ruleset mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
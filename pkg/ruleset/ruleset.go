@@ -1,9 +1,37 @@
 package ruleset
 
 type Ruleset struct {
+	// Include is a list of rulesets to merge before this one, relative to the directory of this ruleset
+	// Rules in this ruleset are layered on top of the included rules
+	Include []string `yaml:"include,omitempty"`
+
+	// Definitions are named lists of values that matchAny can refer to with ref
+	// They are shared by every ruleset that is loaded together, including across includes
+	Definitions map[string][]interface{} `yaml:"definitions,omitempty"`
+
+	// Templates are named partial rules that rules can extend
+	// Like definitions, they are shared by every ruleset that is loaded together
+	Templates map[string]RuleTemplate `yaml:"templates,omitempty"`
+
+	// Parameters are values given when the rulesets are loaded, which strings in the rulesets refer to as ${var.name}
+	// Like definitions, they are shared by every ruleset that is loaded together
+	Parameters map[string]Parameter `yaml:"parameters,omitempty"`
+
+	// Overlays patch the rules of this ruleset for an environment, which is selected when the rulesets are loaded
+	Overlays map[string]Overlay `yaml:"overlays,omitempty"`
+
+	Variables          *VariableRules               `yaml:"variables,omitempty"`
+	Configuration      *ConfigurationRules          `yaml:"configuration,omitempty"`
 	CreatedResources   *CreateDeleteResourceChanges `yaml:"createdResources,omitempty"`
 	DestroyedResources *CreateDeleteResourceChanges `yaml:"destroyedResources,omitempty"`
 	UpdatedResources   *UpdateResourceChanges       `yaml:"updatedResources,omitempty"`
+	ReadResources      *CreateDeleteResourceChanges `yaml:"readResources,omitempty"`
+
+	// Resources are the rules to apply to existing resources when validating a state
+	Resources *CreateDeleteResourceChanges `yaml:"resources,omitempty"`
+
+	// Waivers are time-boxed exceptions to resource rules
+	Waivers []Waiver `yaml:"waivers,omitempty"`
 }
 
 type CreateDeleteResourceChanges struct {
@@ -18,6 +46,10 @@ type CreateDeleteResourceChanges struct {
 }
 
 type CreateDeleteResourceChange struct {
+	// Extends is the name of the template the rule is based on
+	Extends string `yaml:"extends,omitempty"`
+
+	RuleMetadata       `yaml:",inline"`
 	CompareOptions     `yaml:",inline"`
 	ResourceIdentifier `yaml:",inline"`
 	ResourceRules      `yaml:",inline"`
@@ -35,6 +67,11 @@ type UpdateResourceChanges struct {
 }
 
 type UpdateResourceChange struct {
+	// Extends is the name of the template the rule is based on
+	// The enforced and ignored arguments of the template apply to the after values
+	Extends string `yaml:"extends,omitempty"`
+
+	RuleMetadata       `yaml:",inline"`
 	CompareOptions     `yaml:",inline"`
 	ResourceIdentifier `yaml:",inline"`
 
@@ -42,6 +79,150 @@ type UpdateResourceChange struct {
 	After  *ResourceRules `yaml:"after,omitempty"`
 }
 
+type VariableRules struct {
+	// Default CompareOptions to use for all rules
+	Default *CompareOptions `yaml:"default,omitempty"`
+
+	// Rules is a list of rules to validate the plan's root module input variables against
+	Rules []VariableRule `yaml:"rules"`
+}
+
+type VariableRule struct {
+	// Extends is the name of the template the rule is based on
+	Extends string `yaml:"extends,omitempty"`
+
+	RuleMetadata   `yaml:",inline"`
+	CompareOptions `yaml:",inline"`
+	ResourceRules  `yaml:",inline"`
+
+	// When is a set of conditions on the variables that must all be met for the rule to apply
+	// If omitted, the rule always applies
+	When map[string]EnforceChange `yaml:"when,omitempty"`
+}
+
+// RuleTemplate is a partial rule that rules extend
+// Anything a rule sets itself takes priority over the template, and the enforced and ignored arguments are combined
+type RuleTemplate struct {
+	// Extends is the name of the template this template is based on
+	Extends string `yaml:"extends,omitempty"`
+
+	RuleMetadata   `yaml:",inline"`
+	CompareOptions `yaml:",inline"`
+	ResourceRules  `yaml:",inline"`
+}
+
+// Parameter is a value given when rulesets are loaded, such as a value that differs between environments
+type Parameter struct {
+	Description string `yaml:"description,omitempty"`
+
+	// Type is the type of the value, either "string", "number", "bool" or "list"
+	// If omitted, the value is a string
+	Type string `yaml:"type,omitempty"`
+
+	// Default is the value if none is given
+	// If omitted, a value must be given if the parameter is used
+	Default interface{} `yaml:"default,omitempty"`
+}
+
+// Overlay patches the rules of a ruleset for an environment
+// A rule with the same identifier as a rule of the ruleset patches it like a template, and other rules are added
+type Overlay struct {
+	// Vars are the values of parameters for the environment
+	// Values given when the rulesets are loaded take priority over them
+	Vars map[string]interface{} `yaml:"vars,omitempty"`
+
+	Variables          *VariableRules               `yaml:"variables,omitempty"`
+	CreatedResources   *CreateDeleteResourceChanges `yaml:"createdResources,omitempty"`
+	DestroyedResources *CreateDeleteResourceChanges `yaml:"destroyedResources,omitempty"`
+	UpdatedResources   *UpdateResourceChanges       `yaml:"updatedResources,omitempty"`
+	ReadResources      *CreateDeleteResourceChanges `yaml:"readResources,omitempty"`
+	Resources          *CreateDeleteResourceChanges `yaml:"resources,omitempty"`
+}
+
+type ConfigurationRules struct {
+	// ToolVersion is a version constraint the version of the tool that made the plan must satisfy
+	// It is not checked against the required_version of the configuration, which plans do not include
+	// Example: ">= 0.12, < 0.14"
+	ToolVersion string `yaml:"toolVersion,omitempty"`
+
+	// Tool is the tool the plan must be made with, either "terraform" or "opentofu"
+	Tool string `yaml:"tool,omitempty"`
+
+	// ToolVersions are version constraints that only apply to plans made with the tool
+	// Example: {"terraform": ">= 1.5", "opentofu": ">= 1.6"}
+	ToolVersions map[string]string `yaml:"toolVersions,omitempty"`
+
+	Providers *ProviderRules `yaml:"providers,omitempty"`
+	Modules   *ModuleRules   `yaml:"modules,omitempty"`
+}
+
+type ProviderRules struct {
+	// Allowed is a list of allowed providers
+	// Aliased providers are written as "name.alias"
+	// If empty, all providers are allowed
+	Allowed []string `yaml:"allowed,omitempty"`
+
+	// If requireVersion is enabled, every provider must have a version constraint
+	RequireVersion bool `yaml:"requireVersion,omitempty"`
+}
+
+type ModuleRules struct {
+	// AllowedSources is a list of prefixes a module source must start with
+	// If empty, all sources are allowed
+	AllowedSources []string `yaml:"allowedSources,omitempty"`
+
+	// If denyLocal is enabled, modules sourced from a local path are not allowed
+	DenyLocal bool `yaml:"denyLocal,omitempty"`
+
+	// If requireVersion is enabled, every remote module must have a version constraint
+	// For registry modules this is the version argument, and for other remote sources the "ref" query parameter
+	RequireVersion bool `yaml:"requireVersion,omitempty"`
+
+	// If requirePinnedVersion is enabled, every registry module must be pinned to an exact version
+	RequirePinnedVersion bool `yaml:"requirePinnedVersion,omitempty"`
+}
+
+// RuleMetadata describes the policy a rule enforces, and is shown when the rule fails
+// Every field is optional
+type RuleMetadata struct {
+	// ID is a short, stable name for the rule
+	// Example: "approved-zones"
+	ID string `yaml:"id,omitempty"`
+
+	// Description is what the rule enforces and why
+	Description string `yaml:"description,omitempty"`
+
+	// Severity is how a failure of the rule is treated, either "error", "warning" or "info"
+	// If omitted, the rule is an error
+	Severity string `yaml:"severity,omitempty"`
+
+	// Owner is who to contact about the rule, such as a team
+	Owner string `yaml:"owner,omitempty"`
+
+	// Docs is a link to read about the rule and how to fix a failure
+	Docs string `yaml:"docs,omitempty"`
+}
+
+// Waiver is an exception to a rule for the resources matching an address pattern, until it expires
+type Waiver struct {
+	// Address is a pattern the address of a resource must match, where "*" matches any characters
+	// Example: "module.legacy.google_compute_instance.*"
+	Address string `yaml:"address"`
+
+	// Rule is the id of the waived rule
+	Rule string `yaml:"rule"`
+
+	// Reason is why the exception is granted
+	Reason string `yaml:"reason"`
+
+	// ApprovedBy is who granted the exception
+	ApprovedBy string `yaml:"approvedBy"`
+
+	// Expires is the date the waiver expires on, written as YYYY-MM-DD
+	// From that date, failures of the rule are no longer waived
+	Expires string `yaml:"expires"`
+}
+
 type CompareOptions struct {
 	// If enforceAll is enabled, all Enforced must be present
 	EnforceAll *bool `yaml:"enforceAll,omitempty"`
@@ -67,8 +248,16 @@ type CompareOptions struct {
 type ResourceIdentifier struct {
 	Name string `yaml:"name,omitempty"`
 	Type string `yaml:"type,omitempty"`
+
+	// Mode restricts the rule to "managed" resources or "data" sources
+	// If omitted, the rule matches both
+	Mode string `yaml:"mode,omitempty"`
 	// TODO: index
 	// Index interface{} `yaml:"index,omitempty"`
+
+	// Override marks the rule as intentionally replacing a rule with the same identifier from an earlier ruleset
+	// Without it, merging two rules with the same identifier is an error
+	Override bool `yaml:"override,omitempty"`
 }
 
 type ResourceRules struct {
@@ -79,4 +268,9 @@ type ResourceRules struct {
 type EnforceChange struct {
 	Value    interface{}   `yaml:"value,omitempty"`
 	MatchAny []interface{} `yaml:"matchAny,omitempty"`
+	Match    string        `yaml:"match,omitempty"`
+
+	// MatchAnyRef is the name of the definition matchAny refers to, written as matchAny: {ref: name}
+	// It is replaced with the values of the definition when the ruleset is loaded
+	MatchAnyRef string `yaml:"-"`
 }
@@ -0,0 +1,190 @@
+package ruleset
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// jsonSchemaDraft is the version of JSON Schema the ruleset schema is written in
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemaConstraints add the constraints that validation checks, but the Go types cannot express, to the schema of a type
+var schemaConstraints = map[string]func(schema map[string]interface{}){
+	"CreateDeleteResourceChange": resourceRuleConstraints,
+	"UpdateResourceChange":       resourceRuleConstraints,
+	"VariableRule":               metadataConstraints,
+	"RuleTemplate":               metadataConstraints,
+	"Waiver": func(schema map[string]interface{}) {
+		schema["required"] = []string{"address", "rule", "reason", "approvedBy", "expires"}
+		properties := schema["properties"].(map[string]interface{})
+		properties["expires"] = map[string]interface{}{
+			"type":   "string",
+			"format": "date",
+		}
+	},
+	"Parameter": func(schema map[string]interface{}) {
+		properties := schema["properties"].(map[string]interface{})
+		properties["type"] = map[string]interface{}{
+			"type": "string",
+			"enum": validParamTypes,
+		}
+	},
+	"EnforceChange": func(schema map[string]interface{}) {
+		// matchAny is a list of values, or a ref to a definition
+		properties := schema["properties"].(map[string]interface{})
+		properties["matchAny"] = map[string]interface{}{
+			"anyOf": []interface{}{
+				properties["matchAny"],
+				map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"ref": map[string]interface{}{"type": "string"},
+					},
+					"required":             []string{"ref"},
+					"additionalProperties": false,
+				},
+			},
+		}
+
+		// only one of value, matchAny and match can be set
+		schema["not"] = map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"required": []string{"value", "matchAny"}},
+				map[string]interface{}{"required": []string{"value", "match"}},
+				map[string]interface{}{"required": []string{"matchAny", "match"}},
+			},
+		}
+	},
+}
+
+// resourceRuleConstraints requires a rule to have a name or type, and restricts the mode and severity
+func resourceRuleConstraints(schema map[string]interface{}) {
+	schema["anyOf"] = []interface{}{
+		map[string]interface{}{"required": []string{"name"}},
+		map[string]interface{}{"required": []string{"type"}},
+	}
+	properties := schema["properties"].(map[string]interface{})
+	properties["mode"] = map[string]interface{}{
+		"type": "string",
+		"enum": validModes,
+	}
+	metadataConstraints(schema)
+}
+
+// metadataConstraints restricts the severity of a rule
+func metadataConstraints(schema map[string]interface{}) {
+	properties := schema["properties"].(map[string]interface{})
+	properties["severity"] = map[string]interface{}{
+		"type": "string",
+		"enum": validSeverities,
+	}
+}
+
+// JSONSchema returns a JSON Schema for rulesets generated by the version of akashi, which does not allow unknown fields
+func JSONSchema(version string) ([]byte, error) {
+	g := &schemaGenerator{
+		definitions: make(map[string]interface{}),
+	}
+
+	result := g.structSchema(reflect.TypeOf(Ruleset{}))
+	result["$schema"] = jsonSchemaDraft
+	result["title"] = "akashi ruleset"
+	result["$comment"] = fmt.Sprintf("Generated by akashi %s", version)
+	result["definitions"] = g.definitions
+
+	return json.MarshalIndent(result, "", "  ")
+}
+
+// schemaGenerator generates the schema of Go types from their yaml tags, with named struct types as definitions
+type schemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		// an empty value in YAML decodes into a nil pointer
+		return map[string]interface{}{
+			"anyOf": []interface{}{
+				g.typeSchema(t.Elem()),
+				map[string]interface{}{"type": "null"},
+			},
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.typeSchema(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.typeSchema(t.Elem()),
+		}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.definitions[name]; !ok {
+			// reserve the name first, in case the type refers to itself
+			g.definitions[name] = nil
+			g.definitions[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	default:
+		// interface{} values can be any YAML value
+		return map[string]interface{}{}
+	}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	g.addProperties(properties, t)
+
+	result := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if constrain, ok := schemaConstraints[t.Name()]; ok {
+		constrain(result)
+	}
+	return result
+}
+
+// addProperties adds the fields of the struct as properties, in the same way yaml.v2 decodes them
+func (g *schemaGenerator) addProperties(properties map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tag := f.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		inline := false
+		for _, opt := range parts[1:] {
+			if opt == "inline" {
+				inline = true
+			}
+		}
+
+		if inline {
+			g.addProperties(properties, f.Type)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		properties[name] = g.typeSchema(f.Type)
+	}
+}
@@ -0,0 +1,62 @@
+package ruleset
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema("1.2.3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var schema struct {
+		Comment     string `json:"$comment"`
+		Properties  map[string]interface{}
+		Definitions map[string]struct {
+			AdditionalProperties bool `json:"additionalProperties"`
+			Properties           map[string]map[string]interface{}
+			AnyOf                []map[string][]string `json:"anyOf"`
+			Not                  map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if schema.Comment != "Generated by akashi 1.2.3" {
+		t.Errorf("Expected the schema to include the version, got %q", schema.Comment)
+	}
+	if _, ok := schema.Properties["include"]; !ok {
+		t.Errorf("Expected include to be a property of the ruleset")
+	}
+
+	for name, d := range schema.Definitions {
+		if d.AdditionalProperties {
+			t.Errorf("%s: expected unknown fields to not be allowed", name)
+		}
+	}
+
+	// inline fields are properties of the struct they are inlined in
+	rule := schema.Definitions["CreateDeleteResourceChange"]
+	for _, p := range []string{"name", "type", "mode", "override", "enforced", "ignored", "autoFail"} {
+		if _, ok := rule.Properties[p]; !ok {
+			t.Errorf("Expected %s to be a property of a created or destroyed resource rule", p)
+		}
+	}
+	if diff := cmp.Diff([]interface{}{"", "managed", "data"}, rule.Properties["mode"]["enum"]); diff != "" {
+		t.Errorf("Unexpected mode values: %s", diff)
+	}
+	if diff := cmp.Diff([]interface{}{"", "error", "warning", "info"}, rule.Properties["severity"]["enum"]); diff != "" {
+		t.Errorf("Unexpected severity values: %s", diff)
+	}
+	if diff := cmp.Diff([]map[string][]string{{"required": {"name"}}, {"required": {"type"}}}, rule.AnyOf); diff != "" {
+		t.Errorf("Expected a name or type to be required: %s", diff)
+	}
+	if schema.Definitions["EnforceChange"].Not == nil {
+		t.Errorf("Expected value, matchAny and match to be exclusive")
+	}
+}
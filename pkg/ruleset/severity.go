@@ -0,0 +1,24 @@
+package ruleset
+
+// Severities of a failing rule
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// severityLevels ranks the severities, where a rule without a severity is an error
+var severityLevels = map[string]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+	"":              3,
+}
+
+// validSeverities are the values a rule's severity can be set to
+var validSeverities = []string{"", SeverityError, SeverityWarning, SeverityInfo}
+
+// SeverityAtLeast returns true if the severity is at least as severe as the threshold
+func SeverityAtLeast(severity, threshold string) bool {
+	return severityLevels[severity] >= severityLevels[threshold]
+}
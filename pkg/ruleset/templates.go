@@ -0,0 +1,392 @@
+package ruleset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// enforceChangeYAML is how an EnforceChange is written, where matchAny is either a list of values or a ref to a definition
+type enforceChangeYAML struct {
+	Value    interface{} `yaml:"value,omitempty"`
+	MatchAny interface{} `yaml:"matchAny,omitempty"`
+	Match    string      `yaml:"match,omitempty"`
+}
+
+func (e *EnforceChange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw enforceChangeYAML
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	*e = EnforceChange{
+		Value: raw.Value,
+		Match: raw.Match,
+	}
+	switch m := raw.MatchAny.(type) {
+	case nil:
+	case []interface{}:
+		e.MatchAny = m
+	case map[interface{}]interface{}:
+		ref, ok := m["ref"].(string)
+		if !ok || ref == "" || len(m) != 1 {
+			return invalidMatchAny(unmarshal)
+		}
+		e.MatchAnyRef = ref
+	default:
+		return invalidMatchAny(unmarshal)
+	}
+	return nil
+}
+
+// invalidMatchAny returns the error for a matchAny that is neither a list nor a ref, on the line of the value
+func invalidMatchAny(unmarshal func(interface{}) error) error {
+	// decoding the value as a list fails, and the error has the line of the value
+	var list struct {
+		Value    interface{}   `yaml:"value"`
+		MatchAny []interface{} `yaml:"matchAny"`
+		Match    string        `yaml:"match"`
+	}
+	message := "matchAny must be a list of values, or a ref to a definition written as {ref: name}"
+	if line := probeLine(unmarshal, &list); line != 0 {
+		message = fmt.Sprintf("line %d: %s", line, message)
+	}
+	return &yaml.TypeError{Errors: []string{message}}
+}
+
+// resolveLayers replaces the refs to definitions and the templates rules extend in every layer
+func resolveLayers(layers []layer) error {
+	r := newResolver(layers)
+
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.resolveTemplate(name, nil)
+	}
+
+	for i := range layers {
+		r.resolveLayer(i)
+	}
+	if len(r.errors) > 0 {
+		return r.errors
+	}
+
+	for i := range layers {
+		layers[i].ruleset.Definitions = nil
+		layers[i].ruleset.Templates = nil
+	}
+	return nil
+}
+
+// resolver resolves the refs and templates of layers
+type resolver struct {
+	layers []layer
+
+	// roots are the decoded YAML of each layer, to find the line of an error
+	roots map[int]*yamlNode
+
+	definitions map[string][]interface{}
+	templates   map[string]RuleTemplate
+
+	// origins are the indexes of the layers each definition and template is in
+	definitionOrigins map[string]int
+	templateOrigins   map[string]int
+
+	// resolved are the templates with the templates they extend applied
+	resolved map[string]RuleTemplate
+
+	// invalid are the templates that cannot be resolved, which have already been reported
+	invalid map[string]bool
+
+	errors ValidationErrors
+}
+
+func newResolver(layers []layer) *resolver {
+	r := &resolver{
+		layers:            layers,
+		roots:             make(map[int]*yamlNode),
+		definitions:       make(map[string][]interface{}),
+		templates:         make(map[string]RuleTemplate),
+		definitionOrigins: make(map[string]int),
+		templateOrigins:   make(map[string]int),
+		resolved:          make(map[string]RuleTemplate),
+		invalid:           make(map[string]bool),
+	}
+
+	for i, l := range layers {
+		definitions := make([]string, 0, len(l.ruleset.Definitions))
+		for name := range l.ruleset.Definitions {
+			definitions = append(definitions, name)
+		}
+		sort.Strings(definitions)
+		for _, name := range definitions {
+			if origin, ok := r.definitionOrigins[name]; ok {
+				r.add(i, []interface{}{"definitions", name}, fmt.Sprintf("definition %q is already defined in %s", name, layers[origin].path))
+				continue
+			}
+			r.definitions[name] = l.ruleset.Definitions[name]
+			r.definitionOrigins[name] = i
+		}
+
+		templates := make([]string, 0, len(l.ruleset.Templates))
+		for name := range l.ruleset.Templates {
+			templates = append(templates, name)
+		}
+		sort.Strings(templates)
+		for _, name := range templates {
+			if origin, ok := r.templateOrigins[name]; ok {
+				r.add(i, []interface{}{"templates", name}, fmt.Sprintf("template %q is already defined in %s", name, layers[origin].path))
+				continue
+			}
+			r.templates[name] = l.ruleset.Templates[name]
+			r.templateOrigins[name] = i
+		}
+	}
+
+	return r
+}
+
+// resolveTemplate returns the template with the templates it extends applied, or false if it cannot be resolved
+func (r *resolver) resolveTemplate(name string, chain []string) (RuleTemplate, bool) {
+	if t, ok := r.resolved[name]; ok {
+		return t, true
+	}
+	if r.invalid[name] {
+		return RuleTemplate{}, false
+	}
+
+	origin := r.templateOrigins[name]
+	path := []interface{}{"templates", name}
+	for i, c := range chain {
+		if c == name {
+			cycle := append(append([]string(nil), chain[i:]...), name)
+			r.add(origin, appendPath(path, "extends"), fmt.Sprintf("template cycle: %s", strings.Join(cycle, " -> ")))
+			for _, t := range cycle {
+				r.invalid[t] = true
+			}
+			return RuleTemplate{}, false
+		}
+	}
+
+	t := r.templates[name]
+	r.resolveRefs(origin, appendPath(path, "enforced"), t.Enforced)
+	if t.Extends != "" {
+		if _, ok := r.templates[t.Extends]; !ok {
+			r.add(origin, appendPath(path, "extends"), fmt.Sprintf("template %q extends unknown template %q", name, t.Extends))
+			r.invalid[name] = true
+			return RuleTemplate{}, false
+		}
+		parent, ok := r.resolveTemplate(t.Extends, append(chain, name))
+		if !ok {
+			r.invalid[name] = true
+			return RuleTemplate{}, false
+		}
+
+		t.RuleMetadata = extendMetadata(t.RuleMetadata, parent.RuleMetadata)
+		t.CompareOptions = withDefaults(t.CompareOptions, &parent.CompareOptions)
+		t.ResourceRules = extendRules(t.ResourceRules, parent.ResourceRules)
+		t.Extends = ""
+	}
+
+	r.resolved[name] = t
+	return t, true
+}
+
+// template returns the resolved template a rule extends, or false if it cannot be used
+func (r *resolver) template(layer int, path []interface{}, name string) (RuleTemplate, bool) {
+	if _, ok := r.templates[name]; !ok {
+		r.add(layer, path, fmt.Sprintf("unknown template %q", name))
+		return RuleTemplate{}, false
+	}
+	// a template that cannot be resolved is already reported where it is defined
+	return r.resolveTemplate(name, nil)
+}
+
+func (r *resolver) resolveLayer(i int) {
+	rs := r.layers[i].ruleset
+	r.resolveSections(i, nil, rs.sections())
+
+	envs := make([]string, 0, len(rs.Overlays))
+	for env := range rs.Overlays {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		r.resolveSections(i, []interface{}{"overlays", env}, rs.Overlays[env].sections())
+	}
+}
+
+// resolveSections resolves the rules of a ruleset or an overlay, whose sections are at the path
+func (r *resolver) resolveSections(i int, path []interface{}, s ruleSections) {
+	r.resolveCreateDelete(i, appendPath(path, "createdResources"), s.created)
+	r.resolveCreateDelete(i, appendPath(path, "destroyedResources"), s.destroyed)
+	r.resolveCreateDelete(i, appendPath(path, "readResources"), s.read)
+	r.resolveCreateDelete(i, appendPath(path, "resources"), s.resources)
+
+	if s.updated != nil {
+		for j := range s.updated.Resources {
+			rule := &s.updated.Resources[j]
+			path := appendPath(path, "updatedResources", "resources", j)
+			if rule.Before != nil {
+				r.resolveRefs(i, appendPath(path, "before", "enforced"), rule.Before.Enforced)
+			}
+			if rule.After != nil {
+				r.resolveRefs(i, appendPath(path, "after", "enforced"), rule.After.Enforced)
+			}
+			if rule.Extends == "" {
+				continue
+			}
+
+			if t, ok := r.template(i, appendPath(path, "extends"), rule.Extends); ok {
+				rule.RuleMetadata = extendMetadata(rule.RuleMetadata, t.RuleMetadata)
+				rule.CompareOptions = withDefaults(rule.CompareOptions, &t.CompareOptions)
+				if len(t.Enforced) > 0 || len(t.Ignored) > 0 {
+					if rule.After == nil {
+						rule.After = &ResourceRules{}
+					}
+					*rule.After = extendRules(*rule.After, t.ResourceRules)
+				}
+			}
+			rule.Extends = ""
+		}
+	}
+
+	if s.variables != nil {
+		for j := range s.variables.Rules {
+			rule := &s.variables.Rules[j]
+			path := appendPath(path, "variables", "rules", j)
+			r.resolveRefs(i, appendPath(path, "enforced"), rule.Enforced)
+			r.resolveRefs(i, appendPath(path, "when"), rule.When)
+			if rule.Extends == "" {
+				continue
+			}
+
+			if t, ok := r.template(i, appendPath(path, "extends"), rule.Extends); ok {
+				rule.RuleMetadata = extendMetadata(rule.RuleMetadata, t.RuleMetadata)
+				rule.CompareOptions = withDefaults(rule.CompareOptions, &t.CompareOptions)
+				rule.ResourceRules = extendRules(rule.ResourceRules, t.ResourceRules)
+			}
+			rule.Extends = ""
+		}
+	}
+}
+
+func (r *resolver) resolveCreateDelete(i int, path []interface{}, rules *CreateDeleteResourceChanges) {
+	if rules == nil {
+		return
+	}
+
+	for j := range rules.Resources {
+		rule := &rules.Resources[j]
+		path := appendPath(path, "resources", j)
+		r.resolveRefs(i, appendPath(path, "enforced"), rule.Enforced)
+		if rule.Extends == "" {
+			continue
+		}
+
+		if t, ok := r.template(i, appendPath(path, "extends"), rule.Extends); ok {
+			rule.RuleMetadata = extendMetadata(rule.RuleMetadata, t.RuleMetadata)
+			rule.CompareOptions = withDefaults(rule.CompareOptions, &t.CompareOptions)
+			rule.ResourceRules = extendRules(rule.ResourceRules, t.ResourceRules)
+		}
+		rule.Extends = ""
+	}
+}
+
+// resolveRefs replaces each matchAny ref with the values of the definition it refers to
+func (r *resolver) resolveRefs(layer int, path []interface{}, enforced map[string]EnforceChange) {
+	keys := make([]string, 0, len(enforced))
+	for k := range enforced {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		e := enforced[k]
+		if e.MatchAnyRef == "" {
+			continue
+		}
+
+		values, ok := r.definitions[e.MatchAnyRef]
+		if !ok {
+			r.add(layer, appendPath(path, k, "matchAny"), fmt.Sprintf("%s: unknown definition %q", k, e.MatchAnyRef))
+			continue
+		}
+		// an empty definition is still set, so it matches nothing
+		e.MatchAny = append([]interface{}{}, values...)
+		e.MatchAnyRef = ""
+		enforced[k] = e
+	}
+}
+
+func (r *resolver) add(layer int, path []interface{}, message string) {
+	root, ok := r.roots[layer]
+	if !ok {
+		root = &yamlNode{}
+		yaml.Unmarshal(r.layers[layer].data, root)
+		r.roots[layer] = root
+	}
+
+	r.errors = append(r.errors, ValidationError{
+		Path:    r.layers[layer].path,
+		Line:    root.lineOf(path...),
+		Message: message,
+	})
+}
+
+// extendMetadata returns the metadata with every unset field taken from the template
+func extendMetadata(meta, t RuleMetadata) RuleMetadata {
+	if meta.ID == "" {
+		meta.ID = t.ID
+	}
+	if meta.Description == "" {
+		meta.Description = t.Description
+	}
+	if meta.Severity == "" {
+		meta.Severity = t.Severity
+	}
+	if meta.Owner == "" {
+		meta.Owner = t.Owner
+	}
+	if meta.Docs == "" {
+		meta.Docs = t.Docs
+	}
+	return meta
+}
+
+// extendRules returns the rules combined with the rules of the template, except for arguments the rules enforce or ignore
+func extendRules(rules, t ResourceRules) ResourceRules {
+	ignored := make(map[string]bool)
+	for _, k := range rules.Ignored {
+		ignored[k] = true
+	}
+
+	result := ResourceRules{
+		Ignored: append([]string(nil), rules.Ignored...),
+	}
+	if len(rules.Enforced) > 0 || len(t.Enforced) > 0 {
+		result.Enforced = make(map[string]EnforceChange)
+		for k, e := range t.Enforced {
+			if !ignored[k] {
+				result.Enforced[k] = e
+			}
+		}
+		for k, e := range rules.Enforced {
+			result.Enforced[k] = e
+		}
+	}
+	for _, k := range t.Ignored {
+		if _, ok := rules.Enforced[k]; ok || ignored[k] {
+			continue
+		}
+		ignored[k] = true
+		result.Ignored = append(result.Ignored, k)
+	}
+
+	return result
+}
@@ -0,0 +1,261 @@
+package ruleset
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	dir := writeRulesets(t, map[string]string{
+		"shared.yaml": `
+definitions:
+  allowedZones:
+  - us-central1-a
+  - us-central1-b
+  noZones: []
+templates:
+  baseInstanceRule:
+    id: approved-instances
+    owner: platform-team
+    enforceAll: true
+    enforced:
+      zone:
+        matchAny: {ref: allowedZones}
+    ignored:
+    - labels
+  webInstanceRule:
+    extends: baseInstanceRule
+    severity: warning
+    enforced:
+      machine_type:
+        value: n1-standard-1
+`,
+		"team.yaml": `
+include:
+- shared.yaml
+createdResources:
+  resources:
+  - type: google_compute_instance
+    extends: webInstanceRule
+    owner: web-team
+    enforceAll: false
+    enforced:
+      machine_type:
+        value: n1-standard-2
+  - type: google_compute_disk
+    extends: baseInstanceRule
+    ignored:
+    - zone
+    enforced:
+      labels:
+        value: {}
+updatedResources:
+  resources:
+  - type: google_compute_instance
+    extends: baseInstanceRule
+variables:
+  rules:
+  - enforced:
+      region:
+        matchAny: {ref: noZones}
+`,
+		"unknown.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    extends: missingRule
+    enforced:
+      zone:
+        matchAny: {ref: missingZones}
+`,
+		"cycle.yaml": `
+templates:
+  a:
+    extends: b
+  b:
+    extends: a
+  c:
+    extends: a
+createdResources:
+  resources:
+  - type: google_compute_instance
+    extends: c
+`,
+		"unknown-parent.yaml": `
+templates:
+  a:
+    extends: missing
+`,
+		"duplicate.yaml": `
+include:
+- shared.yaml
+definitions:
+  allowedZones:
+  - europe-west1-b
+`,
+		"invalid-ref.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        matchAny: {name: allowedZones}
+`,
+		"unknown-field.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        matchAll: [us-central1-a]
+`,
+		"value-and-ref.yaml": `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        value: us-central1-a
+        matchAny: {ref: allowedZones}
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	cases := map[string]struct {
+		paths    []string
+		expected Ruleset
+		err      []string
+	}{
+		"templates and definitions from an included ruleset": {
+			paths: []string{"team.yaml"},
+			expected: Ruleset{
+				CreatedResources: &CreateDeleteResourceChanges{
+					Resources: []CreateDeleteResourceChange{
+						{
+							RuleMetadata: RuleMetadata{
+								ID:       "approved-instances",
+								Severity: "warning",
+								Owner:    "web-team",
+							},
+							CompareOptions:     CompareOptions{EnforceAll: boolPointer(false)},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"zone":         {MatchAny: []interface{}{"us-central1-a", "us-central1-b"}},
+									"machine_type": {Value: "n1-standard-2"},
+								},
+								Ignored: []string{"labels"},
+							},
+						},
+						{
+							RuleMetadata: RuleMetadata{
+								ID:    "approved-instances",
+								Owner: "platform-team",
+							},
+							CompareOptions:     CompareOptions{EnforceAll: boolPointer(true)},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_disk"},
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"labels": {Value: map[interface{}]interface{}{}},
+								},
+								Ignored: []string{"zone"},
+							},
+						},
+					},
+				},
+				UpdatedResources: &UpdateResourceChanges{
+					Resources: []UpdateResourceChange{
+						{
+							RuleMetadata: RuleMetadata{
+								ID:    "approved-instances",
+								Owner: "platform-team",
+							},
+							CompareOptions:     CompareOptions{EnforceAll: boolPointer(true)},
+							ResourceIdentifier: ResourceIdentifier{Type: "google_compute_instance"},
+							After: &ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"zone": {MatchAny: []interface{}{"us-central1-a", "us-central1-b"}},
+								},
+								Ignored: []string{"labels"},
+							},
+						},
+					},
+				},
+				Variables: &VariableRules{
+					Rules: []VariableRule{
+						{
+							ResourceRules: ResourceRules{
+								Enforced: map[string]EnforceChange{
+									"region": {MatchAny: []interface{}{}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"unknown template and definition": {
+			paths: []string{"unknown.yaml"},
+			err: []string{
+				`unknown.yaml:5: unknown template "missingRule"`,
+				`unknown.yaml:8: zone: unknown definition "missingZones"`,
+			},
+		},
+		"template cycle": {
+			paths: []string{"cycle.yaml"},
+			err:   []string{"cycle.yaml:4: template cycle: a -> b -> a"},
+		},
+		"template extends unknown template": {
+			paths: []string{"unknown-parent.yaml"},
+			err:   []string{`unknown-parent.yaml:4: template "a" extends unknown template "missing"`},
+		},
+		"duplicate definition": {
+			paths: []string{"duplicate.yaml"},
+			err:   []string{`duplicate.yaml:6: definition "allowedZones" is already defined in`},
+		},
+		"invalid ref": {
+			paths: []string{"invalid-ref.yaml"},
+			err:   []string{"invalid-ref.yaml:7: matchAny must be a list of values, or a ref to a definition"},
+		},
+		"unknown field in enforced": {
+			paths: []string{"unknown-field.yaml"},
+			err:   []string{`unknown-field.yaml:7: unknown field "matchAll"`},
+		},
+		"value and ref": {
+			paths: []string{"value-and-ref.yaml"},
+			err:   []string{"zone: only one of value, matchAny and match can be set, but value and matchAny are set"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var paths []string
+			for _, p := range tc.paths {
+				paths = append(paths, filepath.Join(dir, p))
+			}
+
+			got, err := Load(paths...)
+			if len(tc.err) > 0 {
+				if err == nil {
+					t.Fatalf("Expected an error but got none")
+				}
+				for _, e := range tc.err {
+					if !strings.Contains(err.Error(), e) {
+						t.Errorf("Expected error to contain %q but got: %v", e, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, got); diff != "" {
+				t.Errorf("Ruleset mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package ruleset
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// unknownFieldPattern matches the error yaml.v2 reports for an unknown field when decoding strictly
+var unknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+
+// managedMode and dataMode are the modes a rule can be restricted to
+const (
+	managedMode = "managed"
+	dataMode    = "data"
+)
+
+// validModes are the values a rule's mode can be set to
+var validModes = []string{"", managedMode, dataMode}
+
+// ValidationError is a problem found in a ruleset
+type ValidationError struct {
+	// Path is the path of the ruleset
+	Path string
+
+	// Line is the line of the problem, or 0 if it is not known
+	Line int
+
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Message)
+}
+
+// ValidationErrors are every problem found in a ruleset, in the order they appear
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	var lines []string
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// problem is a problem found in a decoded ruleset, at the path of mapping keys and sequence indexes
+type problem struct {
+	path    []interface{}
+	message string
+}
+
+// decodeRuleset strictly decodes the ruleset read from path, without validating its rules
+func decodeRuleset(path string, data []byte) (Ruleset, error) {
+	var rs Ruleset
+	if err := yaml.UnmarshalStrict(data, &rs); err != nil {
+		return rs, yamlValidationErrors(path, err)
+	}
+	return rs, nil
+}
+
+// validateLayer returns the problems with the rules of the layer that cannot work as written
+func validateLayer(l layer) error {
+	if errs := l.errors(validateRuleset(l.ruleset)); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// errors converts problems found in the layer into validation errors, in the order they appear
+func (l layer) errors(problems []problem) ValidationErrors {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// the ruleset decoded, so the positions of its values can be decoded as well
+	var root yamlNode
+	yaml.Unmarshal(l.data, &root)
+
+	var result ValidationErrors
+	for _, p := range problems {
+		result = append(result, ValidationError{
+			Path:    l.path,
+			Line:    root.lineOf(p.path...),
+			Message: p.message,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
+// yamlValidationErrors converts the errors of yaml.v2 into validation errors
+func yamlValidationErrors(path string, err error) ValidationErrors {
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+
+	var result ValidationErrors
+	for _, m := range messages {
+		line, message := splitYAMLError(m)
+		if match := unknownFieldPattern.FindStringSubmatch(message); match != nil {
+			message = fmt.Sprintf("unknown field %q", match[1])
+		}
+		result = append(result, ValidationError{
+			Path:    path,
+			Line:    line,
+			Message: message,
+		})
+	}
+	return result
+}
+
+// validateRuleset returns the problems with rules that decode, but cannot work as written
+func validateRuleset(rs Ruleset) []problem {
+	result := validateSections(nil, rs.sections())
+
+	overlays := make([]string, 0, len(rs.Overlays))
+	for env := range rs.Overlays {
+		overlays = append(overlays, env)
+	}
+	sort.Strings(overlays)
+	for _, env := range overlays {
+		result = append(result, validateSections([]interface{}{"overlays", env}, rs.Overlays[env].sections())...)
+	}
+
+	names := make([]string, 0, len(rs.Templates))
+	for name := range rs.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := rs.Templates[name]
+		path := []interface{}{"templates", name}
+		result = append(result, validateMetadata(path, t.RuleMetadata)...)
+		result = append(result, validateEnforced(appendPath(path, "enforced"), t.Enforced)...)
+	}
+
+	for i, w := range rs.Waivers {
+		result = append(result, validateWaiver([]interface{}{"waivers", i}, w)...)
+	}
+
+	return result
+}
+
+// validateSections validates the rules of a ruleset or an overlay, whose sections are at the path
+func validateSections(path []interface{}, s ruleSections) []problem {
+	var result []problem
+	result = append(result, validateCreateDelete(appendPath(path, "createdResources"), s.created)...)
+	result = append(result, validateCreateDelete(appendPath(path, "destroyedResources"), s.destroyed)...)
+	result = append(result, validateCreateDelete(appendPath(path, "readResources"), s.read)...)
+	result = append(result, validateCreateDelete(appendPath(path, "resources"), s.resources)...)
+
+	if s.updated != nil {
+		for i, r := range s.updated.Resources {
+			path := appendPath(path, "updatedResources", "resources", i)
+			result = append(result, validateIdentifier(path, r.ResourceIdentifier)...)
+			result = append(result, validateMetadata(path, r.RuleMetadata)...)
+			if r.Before != nil {
+				result = append(result, validateEnforced(appendPath(path, "before", "enforced"), r.Before.Enforced)...)
+			}
+			if r.After != nil {
+				result = append(result, validateEnforced(appendPath(path, "after", "enforced"), r.After.Enforced)...)
+			}
+		}
+	}
+
+	if s.variables != nil {
+		for i, r := range s.variables.Rules {
+			path := appendPath(path, "variables", "rules", i)
+			result = append(result, validateMetadata(path, r.RuleMetadata)...)
+			result = append(result, validateEnforced(appendPath(path, "enforced"), r.Enforced)...)
+			result = append(result, validateEnforced(appendPath(path, "when"), r.When)...)
+		}
+	}
+
+	return result
+}
+
+func validateCreateDelete(path []interface{}, rules *CreateDeleteResourceChanges) []problem {
+	if rules == nil {
+		return nil
+	}
+
+	var result []problem
+	for i, r := range rules.Resources {
+		path := appendPath(path, "resources", i)
+		result = append(result, validateIdentifier(path, r.ResourceIdentifier)...)
+		result = append(result, validateMetadata(path, r.RuleMetadata)...)
+		result = append(result, validateEnforced(appendPath(path, "enforced"), r.Enforced)...)
+	}
+	return result
+}
+
+func validateIdentifier(path []interface{}, id ResourceIdentifier) []problem {
+	var result []problem
+	if id.Name == "" && id.Type == "" {
+		result = append(result, problem{
+			path:    path,
+			message: "rule has neither name nor type, so it never matches",
+		})
+	}
+
+	valid := false
+	for _, m := range validModes {
+		if id.Mode == m {
+			valid = true
+		}
+	}
+	if !valid {
+		result = append(result, problem{
+			path:    appendPath(path, "mode"),
+			message: fmt.Sprintf("invalid mode %q, must be managed or data", id.Mode),
+		})
+	}
+	return result
+}
+
+func validateMetadata(path []interface{}, meta RuleMetadata) []problem {
+	for _, s := range validSeverities {
+		if meta.Severity == s {
+			return nil
+		}
+	}
+	return []problem{
+		{
+			path:    appendPath(path, "severity"),
+			message: fmt.Sprintf("invalid severity %q, must be error, warning or info", meta.Severity),
+		},
+	}
+}
+
+func validateParameter(path []interface{}, name string, p Parameter) []problem {
+	var result []problem
+	if !paramNamePattern.MatchString(name) {
+		result = append(result, problem{
+			path:    path,
+			message: fmt.Sprintf("invalid parameter name %q, must start with a letter or underscore, followed by letters, digits, underscores or dashes", name),
+		})
+	}
+
+	valid := false
+	for _, t := range validParamTypes {
+		if p.Type == t {
+			valid = true
+		}
+	}
+	if !valid {
+		result = append(result, problem{
+			path:    appendPath(path, "type"),
+			message: fmt.Sprintf("invalid parameter type %q, must be string, number, bool or list", p.Type),
+		})
+	} else if p.Default != nil && !isParamType(p.Type, p.Default) {
+		result = append(result, problem{
+			path:    appendPath(path, "default"),
+			message: fmt.Sprintf("default of parameter %q must be a %s", name, paramType(p.Type)),
+		})
+	}
+	return result
+}
+
+func validateWaiver(path []interface{}, w Waiver) []problem {
+	var result []problem
+	required := []struct {
+		field string
+		value string
+	}{
+		{"address", w.Address},
+		{"rule", w.Rule},
+		{"reason", w.Reason},
+		{"approvedBy", w.ApprovedBy},
+		{"expires", w.Expires},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			result = append(result, problem{
+				path:    path,
+				message: fmt.Sprintf("waiver has no %s", r.field),
+			})
+		}
+	}
+
+	if w.Expires != "" {
+		if _, err := ParseExpiry(w.Expires); err != nil {
+			result = append(result, problem{
+				path:    appendPath(path, "expires"),
+				message: fmt.Sprintf("invalid expiry date %q, must be written as YYYY-MM-DD", w.Expires),
+			})
+		}
+	}
+	return result
+}
+
+func validateEnforced(path []interface{}, enforced map[string]EnforceChange) []problem {
+	keys := make([]string, 0, len(enforced))
+	for k := range enforced {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var result []problem
+	for _, k := range keys {
+		e := enforced[k]
+		var set []string
+		if e.Value != nil {
+			set = append(set, "value")
+		}
+		if e.MatchAny != nil || e.MatchAnyRef != "" {
+			set = append(set, "matchAny")
+		}
+		if e.Match != "" {
+			set = append(set, "match")
+		}
+		if len(set) > 1 {
+			result = append(result, problem{
+				path:    appendPath(path, k),
+				message: fmt.Sprintf("%s: only one of value, matchAny and match can be set, but %s are set", k, strings.Join(set, " and ")),
+			})
+		}
+
+		if e.Match != "" {
+			if _, err := regexp.Compile(e.Match); err != nil {
+				result = append(result, problem{
+					path:    appendPath(path, k, "match"),
+					message: fmt.Sprintf("%s: invalid match pattern: %v", k, err),
+				})
+			}
+		}
+	}
+	return result
+}
+
+// appendPath returns a new path with the elements appended, so paths can be shared between rules
+func appendPath(path []interface{}, elems ...interface{}) []interface{} {
+	result := make([]interface{}, 0, len(path)+len(elems))
+	result = append(result, path...)
+	return append(result, elems...)
+}
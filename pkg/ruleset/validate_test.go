@@ -0,0 +1,150 @@
+package ruleset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeRuleset(t *testing.T) {
+	cases := map[string]struct {
+		input    string
+		expected ValidationErrors
+	}{
+		"valid ruleset": {
+			input: `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    enforced:
+      zone:
+        matchAny:
+        - us-central1-a
+`,
+		},
+		"rule metadata": {
+			input: `
+createdResources:
+  resources:
+  - type: google_compute_instance
+    id: approved-zones
+    description: Instances must be created in an approved zone
+    severity: warning
+    owner: platform-team
+    docs: https://example.com/approved-zones
+updatedResources:
+  resources:
+  - type: google_compute_instance
+    id: approved-zones
+variables:
+  rules:
+  - id: prod-only
+    enforced:
+      environment:
+        value: prod
+`,
+		},
+		"invalid waivers": {
+			input: `
+waivers:
+- address: module.legacy.*
+  rule: approved-zones
+  reason: migrating to the new zones
+  approvedBy: platform-team
+  expires: 2026-11-01
+- address: google_compute_instance.web
+  rule: approved-zones
+  expires: next week
+`,
+			expected: ValidationErrors{
+				{Path: "ruleset.yaml", Line: 8, Message: "waiver has no reason"},
+				{Path: "ruleset.yaml", Line: 8, Message: "waiver has no approvedBy"},
+				{Path: "ruleset.yaml", Line: 10, Message: `invalid expiry date "next week", must be written as YYYY-MM-DD`},
+			},
+		},
+		"unknown fields": {
+			input: `
+createdResources:
+  default:
+    enforcedAll: true
+  resources:
+  - type: google_compute_instance
+    ignore:
+    - labels
+`,
+			expected: ValidationErrors{
+				{Path: "ruleset.yaml", Line: 4, Message: `unknown field "enforcedAll"`},
+				{Path: "ruleset.yaml", Line: 7, Message: `unknown field "ignore"`},
+			},
+		},
+		"wrong type": {
+			input: `
+createdResources:
+  strict: sometimes
+`,
+			expected: ValidationErrors{
+				{Path: "ruleset.yaml", Line: 3, Message: "cannot unmarshal !!str `sometimes` into bool"},
+			},
+		},
+		"syntax error": {
+			input: "createdResources:\n  resources: [\n",
+			expected: ValidationErrors{
+				{Path: "ruleset.yaml", Line: 2, Message: "did not find expected node content"},
+			},
+		},
+		"rules that cannot work": {
+			input: `
+createdResources:
+  resources:
+  - enforced:
+      zone:
+        value: us-central1-a
+  - type: google_compute_instance
+    mode: resource
+  - type: google_compute_disk
+    severity: critical
+updatedResources:
+  resources:
+  - type: google_compute_instance
+    after:
+      enforced:
+        zone:
+          value: us-central1-a
+          matchAny:
+          - us-east1-b
+        name:
+          match: "web-("
+`,
+			expected: ValidationErrors{
+				{Path: "ruleset.yaml", Line: 4, Message: "rule has neither name nor type, so it never matches"},
+				{Path: "ruleset.yaml", Line: 8, Message: `invalid mode "resource", must be managed or data`},
+				{Path: "ruleset.yaml", Line: 10, Message: `invalid severity "critical", must be error, warning or info`},
+				{Path: "ruleset.yaml", Line: 17, Message: "zone: only one of value, matchAny and match can be set, but value and matchAny are set"},
+				{Path: "ruleset.yaml", Line: 21, Message: "name: invalid match pattern: error parsing regexp: missing closing ): `web-(`"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rs, err := decodeRuleset("ruleset.yaml", []byte(tc.input))
+			if err == nil {
+				err = validateLayer(layer{path: "ruleset.yaml", data: []byte(tc.input), ruleset: rs})
+			}
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("Expected validation errors but got %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, errs); diff != "" {
+				t.Errorf("Errors mismatch (-expected +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package ruleset
+
+// ComparesValues returns true if any resource rule compares the values of a resource
+// Every rule compares values unless it sets autoFail, or ignores extra arguments without enforcing or ignoring any
+func (rs Ruleset) ComparesValues() bool {
+	for _, changes := range []*CreateDeleteResourceChanges{rs.CreatedResources, rs.DestroyedResources, rs.ReadResources, rs.Resources} {
+		if changes == nil {
+			continue
+		}
+		for _, r := range changes.Resources {
+			if withDefaults(r.CompareOptions, changes.Default).comparesValues(r.ResourceRules) {
+				return true
+			}
+		}
+	}
+
+	if rs.UpdatedResources != nil {
+		for _, r := range rs.UpdatedResources.Resources {
+			opts := withDefaults(r.CompareOptions, rs.UpdatedResources.Default)
+			if r.Before != nil && opts.comparesValues(*r.Before) {
+				return true
+			}
+			if r.After != nil && opts.comparesValues(*r.After) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// comparesValues returns true if a rule with the options compares values with the rules
+func (o CompareOptions) comparesValues(r ResourceRules) bool {
+	if isTrue(o.AutoFail) {
+		return false
+	}
+	return len(r.Enforced) > 0 || len(r.Ignored) > 0 ||
+		!isTrue(o.IgnoreExtraArgs) || isTrue(o.EnforceAll) || isTrue(o.RequireAll)
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
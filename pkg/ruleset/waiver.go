@@ -0,0 +1,22 @@
+package ruleset
+
+import (
+	"time"
+)
+
+// expiryLayout is the layout of the expiry date of a waiver
+const expiryLayout = "2006-01-02"
+
+// ParseExpiry parses the expiry date of a waiver, which is the start of the day in UTC
+func ParseExpiry(expires string) (time.Time, error) {
+	return time.Parse(expiryLayout, expires)
+}
+
+// Expired returns true if the waiver has expired at the time, or its expiry date cannot be parsed
+func (w Waiver) Expired(now time.Time) bool {
+	expires, err := ParseExpiry(w.Expires)
+	if err != nil {
+		return true
+	}
+	return !now.Before(expires)
+}
@@ -0,0 +1,86 @@
+package ruleset
+
+import (
+	"regexp"
+	"strconv"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// yamlErrorPattern matches the errors of yaml.v2, which start with the line of the value they are about
+// Example: line 5: field enforcedAll not found in type ruleset.CompareOptions
+var yamlErrorPattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlNode is a YAML value along with the line it starts on
+// yaml.v2 does not expose positions, so the line is taken from the error of decoding the value into a type it cannot be decoded as
+type yamlNode struct {
+	line     int
+	children map[string]*yamlNode
+	items    []*yamlNode
+}
+
+func (n *yamlNode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var children map[string]*yamlNode
+	if err := unmarshal(&children); err == nil {
+		n.children = children
+		n.line = probeLine(unmarshal, new(int))
+		return nil
+	}
+
+	var items []*yamlNode
+	if err := unmarshal(&items); err == nil {
+		n.items = items
+		n.line = probeLine(unmarshal, new(int))
+		return nil
+	}
+
+	// null values decode into anything, so their line is not known
+	n.line = probeLine(unmarshal, new([]int))
+	return nil
+}
+
+// probeLine decodes the value into out, which must fail, and returns the line from the error
+func probeLine(unmarshal func(interface{}) error, out interface{}) int {
+	err, ok := unmarshal(out).(*yaml.TypeError)
+	if !ok || len(err.Errors) == 0 {
+		return 0
+	}
+	line, _ := splitYAMLError(err.Errors[0])
+	return line
+}
+
+// splitYAMLError splits a yaml.v2 error into the line, or 0 if it has none, and the message
+func splitYAMLError(s string) (int, string) {
+	match := yamlErrorPattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0, s
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line, match[2]
+}
+
+// lineOf returns the line of the value at the path of mapping keys and sequence indexes
+// If the path does not exist, the line of the closest parent is returned
+func (n *yamlNode) lineOf(path ...interface{}) int {
+	line := n.line
+	current := n
+	for _, p := range path {
+		var next *yamlNode
+		switch p := p.(type) {
+		case string:
+			next = current.children[p]
+		case int:
+			if p >= 0 && p < len(current.items) {
+				next = current.items[p]
+			}
+		}
+		if next == nil {
+			break
+		}
+		current = next
+		if current.line != 0 {
+			line = current.line
+		}
+	}
+	return line
+}
@@ -12,6 +12,7 @@ var (
 	Red    = ansi.ColorFunc("red")
 	Yellow = ansi.ColorFunc("yellow")
 	Green  = ansi.ColorFunc("green")
+	Cyan   = ansi.ColorFunc("cyan")
 	Bold   = ansi.ColorFunc("default+b")
 )
 
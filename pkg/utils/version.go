@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type version struct {
+	segments   [3]int
+	prerelease string
+}
+
+// CheckVersionConstraint returns true if the version satisfies the constraint
+// Constraints use the terraform syntax, a comma separated list of conditions such as ">= 0.12, < 0.14" or "~> 1.2"
+func CheckVersionConstraint(v, constraint string) (bool, error) {
+	parsed, err := parseVersion(v)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		ok, err := checkCondition(parsed, c)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// IsExactVersionConstraint returns true if the constraint only allows a single version
+// Example: "1.2.3" or "= 1.2.3"
+func IsExactVersionConstraint(constraint string) bool {
+	c := strings.TrimSpace(constraint)
+	if strings.HasPrefix(c, "=") {
+		c = strings.TrimSpace(strings.TrimPrefix(c, "="))
+	}
+	if strings.Count(c, ".") != 2 {
+		return false
+	}
+
+	_, err := parseVersion(c)
+	return err == nil
+}
+
+func checkCondition(v version, condition string) (bool, error) {
+	op := ""
+	for _, o := range []string{"~>", ">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(condition, o) {
+			op = o
+			break
+		}
+	}
+	raw := strings.TrimSpace(strings.TrimPrefix(condition, op))
+	target, err := parseVersion(raw)
+	if err != nil {
+		return false, err
+	}
+
+	cmp := compareVersions(v, target)
+	switch op {
+	case "", "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case "~>":
+		// ~> only allows the rightmost specified segment to increase
+		upper := target
+		upper.prerelease = ""
+		switch strings.Count(raw, ".") {
+		case 0:
+			upper.segments = [3]int{target.segments[0] + 1, 0, 0}
+		case 1:
+			upper.segments = [3]int{target.segments[0] + 1, 0, 0}
+		default:
+			upper.segments = [3]int{target.segments[0], target.segments[1] + 1, 0}
+		}
+		return cmp >= 0 && compareVersions(v, upper) < 0, nil
+	}
+
+	return false, fmt.Errorf("unknown version constraint operator in %q", condition)
+}
+
+func parseVersion(v string) (version, error) {
+	var result version
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i != -1 {
+		if v[i] == '-' {
+			result.prerelease = strings.SplitN(v[i+1:], "+", 2)[0]
+		}
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return result, fmt.Errorf("invalid version %q", v)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return result, fmt.Errorf("invalid version %q", v)
+		}
+		result.segments[i] = n
+	}
+
+	return result, nil
+}
+
+// compareVersions returns -1, 0 or 1 if a is less than, equal to or greater than b
+// A prerelease version is less than the same version without a prerelease
+func compareVersions(a, b version) int {
+	for i := range a.segments {
+		if a.segments[i] < b.segments[i] {
+			return -1
+		}
+		if a.segments[i] > b.segments[i] {
+			return 1
+		}
+	}
+
+	switch {
+	case a.prerelease == b.prerelease:
+		return 0
+	case a.prerelease == "":
+		return 1
+	case b.prerelease == "":
+		return -1
+	case a.prerelease < b.prerelease:
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestCheckVersionConstraint(t *testing.T) {
+	cases := map[string]struct {
+		version     string
+		constraint  string
+		expected    bool
+		expectError bool
+	}{
+		"exact match": {
+			version:    "0.12.29",
+			constraint: "0.12.29",
+			expected:   true,
+		},
+		"exact match with operator": {
+			version:    "0.12.29",
+			constraint: "= 0.12.28",
+			expected:   false,
+		},
+		"range": {
+			version:    "0.13.5",
+			constraint: ">= 0.12, < 0.14",
+			expected:   true,
+		},
+		"outside range": {
+			version:    "0.14.0",
+			constraint: ">= 0.12, < 0.14",
+			expected:   false,
+		},
+		"not equal": {
+			version:    "1.0.0",
+			constraint: "!= 1.0.0",
+			expected:   false,
+		},
+		"pessimistic minor": {
+			version:    "1.9.0",
+			constraint: "~> 1.2",
+			expected:   true,
+		},
+		"pessimistic minor major bump": {
+			version:    "2.0.0",
+			constraint: "~> 1.2",
+			expected:   false,
+		},
+		"pessimistic patch": {
+			version:    "1.2.9",
+			constraint: "~> 1.2.3",
+			expected:   true,
+		},
+		"pessimistic patch minor bump": {
+			version:    "1.3.0",
+			constraint: "~> 1.2.3",
+			expected:   false,
+		},
+		"prerelease is lower than release": {
+			version:    "1.0.0-beta1",
+			constraint: ">= 1.0.0",
+			expected:   false,
+		},
+		"v prefix": {
+			version:    "v1.6.0",
+			constraint: ">= 1.6",
+			expected:   true,
+		},
+		"invalid version": {
+			version:     "latest",
+			constraint:  ">= 1.0",
+			expectError: true,
+		},
+		"invalid constraint": {
+			version:     "1.0.0",
+			constraint:  ">= one",
+			expectError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := CheckVersionConstraint(tc.version, tc.constraint)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsExactVersionConstraint(t *testing.T) {
+	cases := map[string]struct {
+		constraint string
+		expected   bool
+	}{
+		"exact version":               {constraint: "1.2.3", expected: true},
+		"exact version with operator": {constraint: "= 1.2.3", expected: true},
+		"partial version":             {constraint: "1.2", expected: false},
+		"range":                       {constraint: ">= 1.2.3", expected: false},
+		"pessimistic":                 {constraint: "~> 1.2.3", expected: false},
+		"empty":                       {constraint: "", expected: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := IsExactVersionConstraint(tc.constraint); got != tc.expected {
+				t.Errorf("Expected: %v but got %v", tc.expected, got)
+			}
+		})
+	}
+}